@@ -0,0 +1,244 @@
+package lib
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// APIDocsFormatJSON renders api_docs output as indented JSON
+	APIDocsFormatJSON = "json"
+	// APIDocsFormatMarkdown renders api_docs output as a Markdown reference
+	APIDocsFormatMarkdown = "markdown"
+	// APIDocsFormatCompact renders api_docs output as a terse summary with one line per property
+	APIDocsFormatCompact = "compact"
+
+	// devBluesURL is the base URL that site-relative links in the Notecard API schema resolve to
+	devBluesURL = "https://dev.blues.io"
+)
+
+// relativeLinkPattern matches the target of a site-relative Markdown link, e.g. "](/api-reference/"
+var relativeLinkPattern = regexp.MustCompile(`\]\((/[^)\s]*)\)`)
+
+// APIDocsFormats lists the output formats of the api_docs tool
+func APIDocsFormats() []string {
+	return []string{APIDocsFormatJSON, APIDocsFormatMarkdown, APIDocsFormatCompact}
+}
+
+// renderAPIMarkdown renders the documentation of an API as a Markdown reference
+func renderAPIMarkdown(api APIEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", api.Name)
+
+	var badges []string
+	if len(api.SKUs) > 0 {
+		badges = append(badges, "**SKUs:** "+skuBadges(api.SKUs))
+	}
+	if api.APIVersion != "" {
+		badges = append(badges, "**API version:** "+api.APIVersion)
+	}
+	if len(badges) > 0 {
+		b.WriteString(strings.Join(badges, " · ") + "\n\n")
+	}
+
+	if api.Description != "" {
+		b.WriteString(absoluteDocLinks(api.Description) + "\n\n")
+	}
+	if api.Annotation != "" {
+		b.WriteString("> **Note:** " + absoluteDocLinks(api.Annotation) + "\n\n")
+	}
+	fmt.Fprintf(&b, "Reference: %s\n", apiReferenceURL(api.Name))
+
+	// Required properties are listed first, then the optional ones
+	var required, optional []string
+	for _, name := range sortedPropertyNames(api.Properties) {
+		if slices.Contains(api.Required, name) {
+			required = append(required, name)
+		} else {
+			optional = append(optional, name)
+		}
+	}
+	writePropertyTable(&b, "Required Properties", required, api)
+	writePropertyTable(&b, "Optional Properties", optional, api)
+
+	// Enum values are described below the tables, as they are too long for a table cell
+	for _, name := range append(required, optional...) {
+		property := api.Properties[name]
+		if len(property.SubDescriptions) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### `%s` values\n\n", name)
+		for _, subDesc := range property.SubDescriptions {
+			line := fmt.Sprintf("- `%s`", subDesc.Const)
+			if subDesc.Description != "" {
+				line += ": " + absoluteDocLinks(oneLine(subDesc.Description))
+			}
+			if len(subDesc.SKUs) > 0 {
+				line += " " + skuBadges(subDesc.SKUs)
+			}
+			if subDesc.APIVersion != "" && subDesc.APIVersion != api.APIVersion {
+				line += fmt.Sprintf(" (firmware %s+)", subDesc.APIVersion)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if len(api.Samples) > 0 || len(api.Examples) > 0 {
+		b.WriteString("\n## Samples\n")
+		for _, sample := range api.Samples {
+			if sample.Title != "" {
+				fmt.Fprintf(&b, "\n### %s\n", sample.Title)
+			}
+			if sample.Description != "" {
+				fmt.Fprintf(&b, "\n%s\n", absoluteDocLinks(sample.Description))
+			}
+			fmt.Fprintf(&b, "\n```json\n%s\n```\n", sample.JSON)
+		}
+		for _, example := range api.Examples {
+			fmt.Fprintf(&b, "\n```json\n%s\n```\n", example)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// writePropertyTable renders the named properties of an API as a Markdown table
+func writePropertyTable(b *strings.Builder, title string, names []string, api APIEntry) {
+	if len(names) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	b.WriteString("| Property | Type | Default | Range | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, name := range names {
+		property := api.Properties[name]
+
+		description := absoluteDocLinks(oneLine(property.Description))
+		if len(property.Enum) > 0 {
+			description += " One of: `" + strings.Join(property.Enum, "`, `") + "`."
+		}
+		if len(property.SKUs) > 0 {
+			description += " " + skuBadges(property.SKUs)
+		}
+		if property.APIVersion != "" && property.APIVersion != api.APIVersion {
+			description += fmt.Sprintf(" (firmware %s+)", property.APIVersion)
+		}
+
+		defaultValue := ""
+		if property.Default != nil {
+			defaultValue = fmt.Sprintf("`%v`", property.Default)
+		}
+		fmt.Fprintf(b, "| `%s` | %s | %s | %s | %s |\n", name, property.Type, defaultValue, propertyRange(property), escapeTableCell(description))
+	}
+}
+
+// renderAPICompact renders an API as a terse summary: the first sentence of its description, its
+// SKUs, one line per property and a sample request. Required properties are marked with '*'.
+func renderAPICompact(api APIEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", api.Name, firstSentence(api.Description))
+	if len(api.SKUs) > 0 {
+		fmt.Fprintf(&b, " [%s]", strings.Join(api.SKUs, ", "))
+	}
+	b.WriteString("\n")
+
+	for _, name := range sortedPropertyNames(api.Properties) {
+		property := api.Properties[name]
+		marker := ""
+		if slices.Contains(api.Required, name) {
+			marker = "*"
+		}
+
+		details := []string{property.Type}
+		if len(property.Enum) > 0 {
+			details = append(details, strings.Join(property.Enum, "|"))
+		}
+		if valueRange := propertyRange(property); valueRange != "" {
+			details = append(details, valueRange)
+		}
+		if property.Default != nil {
+			details = append(details, fmt.Sprintf("default %v", property.Default))
+		}
+		fmt.Fprintf(&b, "- %s%s (%s): %s\n", name, marker, strings.Join(details, ", "), firstSentence(property.Description))
+	}
+
+	if len(api.Samples) > 0 {
+		fmt.Fprintf(&b, "e.g. %s\n", api.Samples[0].JSON)
+	} else if len(api.Examples) > 0 {
+		fmt.Fprintf(&b, "e.g. %s\n", api.Examples[0])
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// renderAPIListMarkdown renders a list of APIs as a Markdown table, or one line per API if compact
+func renderAPIListMarkdown(category *APICategory, compact bool) string {
+	var b strings.Builder
+	if compact {
+		for _, api := range category.APIs {
+			fmt.Fprintf(&b, "%s: %s\n", api.Name, firstSentence(api.Description))
+		}
+		return strings.TrimRight(b.String(), "\n")
+	}
+
+	fmt.Fprintf(&b, "# Notecard APIs\n\n%s\n\n", category.Description)
+	b.WriteString("| API | Description |\n")
+	b.WriteString("| --- | --- |\n")
+	for _, api := range category.APIs {
+		fmt.Fprintf(&b, "| [`%s`](%s) | %s |\n", api.Name, apiReferenceURL(api.Name), escapeTableCell(absoluteDocLinks(firstSentence(api.Description))))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// absoluteDocLinks resolves the site-relative links in schema descriptions to dev.blues.io URLs
+func absoluteDocLinks(text string) string {
+	return relativeLinkPattern.ReplaceAllString(text, "]("+devBluesURL+"$1)")
+}
+
+// skuBadges renders SKUs as inline code badges, e.g. "`CELL` `WIFI`"
+func skuBadges(skus []string) string {
+	return "`" + strings.Join(skus, "` `") + "`"
+}
+
+// propertyRange describes the minimum and maximum of a property, e.g. "1–720" or "≥ 0"
+func propertyRange(property APIProperty) string {
+	switch {
+	case property.Minimum != nil && property.Maximum != nil:
+		return fmt.Sprintf("%v–%v", *property.Minimum, *property.Maximum)
+	case property.Minimum != nil:
+		return fmt.Sprintf("≥ %v", *property.Minimum)
+	case property.Maximum != nil:
+		return fmt.Sprintf("≤ %v", *property.Maximum)
+	}
+	return ""
+}
+
+// sortedPropertyNames returns the names of the properties in alphabetical order
+func sortedPropertyNames(properties map[string]APIProperty) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// firstSentence returns the first sentence of a description, on a single line
+func firstSentence(text string) string {
+	text = oneLine(text)
+	if i := strings.Index(text, ". "); i >= 0 {
+		return text[:i+1]
+	}
+	return text
+}
+
+// oneLine joins the lines of a description with spaces
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// escapeTableCell escapes the pipes of text placed in a Markdown table cell
+func escapeTableCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
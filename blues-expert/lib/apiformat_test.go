@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderAPI(t *testing.T) {
+	minimum, maximum := 1.0, 720.0
+	api := APIEntry{
+		Name:        "card.voltage",
+		Description: "Provides the current V+ voltage. See [modes](/notecard/voltage/#modes) for details.",
+		Properties: map[string]APIProperty{
+			"hours": {Type: "integer", Description: "The number of hours to analyze.", Minimum: &minimum, Maximum: &maximum},
+			"mode": {Type: "string", Description: "Voltage thresholds. | More.", Enum: []string{"lipo", "l91"}, SubDescriptions: []PropertySubDescription{
+				{Const: "lipo", Description: "For LiPo batteries."},
+				{Const: "l91", Description: "For L91 batteries.", SKUs: []string{"CELL"}},
+			}},
+			"vmax": {Type: "number", Description: "Upper limit.", Default: 4.5},
+		},
+		Required: []string{"mode"},
+		Samples:  []APISample{{Title: "LiPo", JSON: `{"req":"card.voltage","mode":"lipo"}`}},
+		SKUs:     []string{"CELL", "WIFI"},
+	}
+
+	markdown := renderAPIMarkdown(api)
+	for _, want := range []string{
+		"**SKUs:** `CELL` `WIFI`",
+		"[modes](https://dev.blues.io/notecard/voltage/#modes)",
+		"## Required Properties\n\n| Property | Type | Default | Range | Description |\n| --- | --- | --- | --- | --- |\n| `mode` | string |  |  | Voltage thresholds. \\| More. One of: `lipo`, `l91`. |",
+		"| `hours` | integer |  | 1–720 | The number of hours to analyze. |",
+		"| `vmax` | number | `4.5` |  | Upper limit. |",
+		"- `l91`: For L91 batteries. `CELL`",
+		"### LiPo\n\n```json\n{\"req\":\"card.voltage\",\"mode\":\"lipo\"}\n```",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("renderAPIMarkdown() missing %q in:\n%s", want, markdown)
+		}
+	}
+
+	compact := renderAPICompact(api)
+	want := "card.voltage: Provides the current V+ voltage. [CELL, WIFI]\n" +
+		"- hours (integer, 1–720): The number of hours to analyze.\n" +
+		"- mode* (string, lipo|l91): Voltage thresholds.\n" +
+		"- vmax (number, default 4.5): Upper limit.\n" +
+		`e.g. {"req":"card.voltage","mode":"lipo"}`
+	if compact != want {
+		t.Errorf("renderAPICompact() = %q, want %q", compact, want)
+	}
+}
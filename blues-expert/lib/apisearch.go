@@ -0,0 +1,239 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// defaultAPISearchLimit is the number of APIs returned by api_search when no limit is given
+	defaultAPISearchLimit = 10
+	// maxAPISearchLimit is the largest number of APIs returned by api_search
+	maxAPISearchLimit = 50
+	// apiNameMatchBoost is added to the score of an API for each keyword matching a part of its name
+	apiNameMatchBoost = 2.0
+)
+
+// APISearchResult is an API matching an api_search query
+type APISearchResult struct {
+	Name               string   `json:"name" jsonschema:"The API name, e.g. 'card.voltage'"`
+	Summary            string   `json:"summary" jsonschema:"The first sentence of the API description"`
+	Score              float64  `json:"score,omitempty" jsonschema:"The relevance of the API to the keywords, if any were given"`
+	MatchingProperties []string `json:"matching_properties,omitempty" jsonschema:"The properties whose name or description mention the keywords"`
+	SKUs               []string `json:"skus,omitempty" jsonschema:"The Notecard SKU families supporting the API. Empty means all"`
+	URL                string   `json:"url" jsonschema:"The API reference on dev.blues.io"`
+}
+
+// APISearchOutput is the structured output of the api_search tool
+type APISearchOutput struct {
+	Query     string            `json:"query,omitempty" jsonschema:"The keywords searched for"`
+	Namespace string            `json:"namespace,omitempty" jsonschema:"The namespace the APIs were limited to"`
+	SKU       string            `json:"sku,omitempty" jsonschema:"The SKU the APIs were limited to"`
+	Total     int               `json:"total" jsonschema:"The number of matching APIs, before the limit was applied"`
+	Results   []APISearchResult `json:"results,omitempty" jsonschema:"The matching APIs, best match first"`
+}
+
+// SearchNotecardAPIs returns the APIs of a schema release in a namespace (e.g. "card" or "card.location.*")
+// and supported by a SKU, ranked by their relevance to the query keywords. Without a query, the APIs
+// are listed by name.
+func SearchNotecardAPIs(query string, namespace string, sku string, limit int, schemaURL string) (*APISearchOutput, error) {
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+	if limit <= 0 {
+		limit = defaultAPISearchLimit
+	}
+	limit = min(limit, maxAPISearchLimit)
+
+	apis, err := loadAPIEntries(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Notecard API schema: %v", err)
+	}
+
+	namespace = normalizeAPINamespace(namespace)
+	if namespace != "" {
+		if namespaces := apiNamespaces(apis); !hasAPINamespace(apis, namespace) {
+			if suggestion := suggestClosest(namespace, namespaces); suggestion != "" {
+				return nil, fmt.Errorf("no APIs in namespace '%s' — did you mean '%s'? Valid namespaces are: %s", namespace, suggestion, strings.Join(namespaces, ", "))
+			}
+			return nil, fmt.Errorf("no APIs in namespace '%s'. Valid namespaces are: %s", namespace, strings.Join(namespaces, ", "))
+		}
+	}
+
+	var target *resolvedTarget
+	if sku != "" {
+		target, err = resolveTarget(ValidationTarget{SKU: sku})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Filter by namespace and SKU
+	var names []string
+	for name, api := range apis {
+		if namespace != "" && name != namespace && !strings.HasPrefix(name, namespace+".") {
+			continue
+		}
+		if target != nil && !target.supportsSKUs(api.SKUs) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := &APISearchOutput{Query: query, Namespace: namespace, SKU: sku}
+	queryTerms := searchTerms(query)
+	if len(queryTerms) == 0 {
+		output.Total = len(names)
+		for _, name := range names[:min(limit, len(names))] {
+			output.Results = append(output.Results, apiSearchResult(apis[name], nil, 0))
+		}
+		return output, nil
+	}
+
+	// Rank the remaining APIs by the keywords, using the same index as the local documentation search.
+	// APIs named after a keyword (e.g. card.location.track for "tracking") rank higher.
+	sections := make([]searchSection, 0, len(names))
+	for _, name := range names {
+		sections = append(sections, newSearchSection(SearchResult{ID: name, Title: name, Content: apiSearchContent(apis[name])}))
+	}
+	scores := make(map[string]float64)
+	for _, match := range newSearchIndex(sections).search(query, len(sections)) {
+		scores[match.ID] = match.Score
+	}
+	for _, name := range names {
+		if matches := apiNameMatches(name, queryTerms); matches > 0 {
+			scores[name] += apiNameMatchBoost * float64(matches)
+		}
+	}
+
+	ranked := make([]string, 0, len(scores))
+	for name := range scores {
+		ranked = append(ranked, name)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+
+	output.Total = len(ranked)
+	for _, name := range ranked[:min(limit, len(ranked))] {
+		output.Results = append(output.Results, apiSearchResult(apis[name], queryTerms, scores[name]))
+	}
+	return output, nil
+}
+
+// apiNameMatches counts the query terms matching a part of an API name, including terms that
+// extend a part (e.g. "tracking" for the "track" of card.location.track)
+func apiNameMatches(name string, queryTerms map[string]bool) int {
+	matches := 0
+	for term := range queryTerms {
+		if len(term) < 3 || strings.Contains(term, ".") {
+			continue
+		}
+		for _, part := range strings.Split(name, ".") {
+			if len(part) >= 3 && (strings.HasPrefix(term, part) || strings.HasPrefix(part, term)) {
+				matches++
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// apiSearchResult summarizes an API for api_search, listing the properties that mention the query terms
+func apiSearchResult(api *APIEntry, queryTerms map[string]bool, score float64) APISearchResult {
+	result := APISearchResult{
+		Name:    api.Name,
+		Summary: firstSentence(api.Description),
+		Score:   math.Round(score*1000) / 1000,
+		SKUs:    api.SKUs,
+		URL:     apiReferenceURL(api.Name),
+	}
+	for _, name := range sortedPropertyNames(api.Properties) {
+		property := api.Properties[name]
+		text := name + " " + property.Description
+		for _, subDesc := range property.SubDescriptions {
+			text += " " + subDesc.Const + " " + subDesc.Description
+		}
+		for _, term := range tokenize(text) {
+			if queryTerms[term] {
+				result.MatchingProperties = append(result.MatchingProperties, name)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// formatAPISearchResults renders api_search results as a Markdown list
+func formatAPISearchResults(output *APISearchOutput) string {
+	var filters []string
+	if output.Query != "" {
+		filters = append(filters, fmt.Sprintf("matching '%s'", output.Query))
+	}
+	if output.Namespace != "" {
+		filters = append(filters, fmt.Sprintf("in %s.*", output.Namespace))
+	}
+	if output.SKU != "" {
+		filters = append(filters, fmt.Sprintf("supported on %s", output.SKU))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d Notecard API(s)", output.Total)
+	if len(filters) > 0 {
+		b.WriteString(" " + strings.Join(filters, ", "))
+	}
+	if len(output.Results) < output.Total {
+		fmt.Fprintf(&b, ", showing the top %d", len(output.Results))
+	}
+	b.WriteString(":\n\n")
+
+	for i, result := range output.Results {
+		fmt.Fprintf(&b, "%d. **%s**: %s", i+1, result.Name, result.Summary)
+		if len(result.SKUs) > 0 {
+			b.WriteString(" " + skuBadges(result.SKUs))
+		}
+		b.WriteString("\n")
+		if len(result.MatchingProperties) > 0 {
+			fmt.Fprintf(&b, "   Matching properties: %s\n", strings.Join(result.MatchingProperties, ", "))
+		}
+	}
+	b.WriteString("\nUse the api_docs tool with one of these API names for its full documentation.")
+	return b.String()
+}
+
+// normalizeAPINamespace converts a namespace filter such as "Card.*" to "card"
+func normalizeAPINamespace(namespace string) string {
+	namespace = strings.ToLower(strings.TrimSpace(namespace))
+	namespace = strings.TrimSuffix(namespace, "*")
+	return strings.TrimSuffix(namespace, ".")
+}
+
+// apiNamespaces returns the top-level namespaces of the APIs, e.g. "card" and "hub"
+func apiNamespaces(apis map[string]*APIEntry) []string {
+	var namespaces []string
+	for name := range apis {
+		namespace, _, _ := strings.Cut(name, ".")
+		if !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// hasAPINamespace reports whether any API is in a namespace, which may be nested (e.g. "card.location")
+func hasAPINamespace(apis map[string]*APIEntry, namespace string) bool {
+	for name := range apis {
+		if name == namespace || strings.HasPrefix(name, namespace+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAPISearchFilters(t *testing.T) {
+	for namespace, want := range map[string]string{"card.*": "card", " Hub. ": "hub", "card.location*": "card.location", "": ""} {
+		if got := normalizeAPINamespace(namespace); got != want {
+			t.Errorf("normalizeAPINamespace(%q) = %q, want %q", namespace, got, want)
+		}
+	}
+
+	apis := map[string]*APIEntry{"card.location.track": {}, "card.voltage": {}, "hub.set": {}}
+	if got := apiNamespaces(apis); !reflect.DeepEqual(got, []string{"card", "hub"}) {
+		t.Errorf("apiNamespaces() = %v", got)
+	}
+	if !hasAPINamespace(apis, "card.location") || hasAPINamespace(apis, "card.loc") {
+		t.Error("hasAPINamespace() matched a partial namespace or missed a nested one")
+	}
+
+	if got := apiNameMatches("card.location.track", searchTerms("gps tracking location")); got != 2 {
+		t.Errorf("apiNameMatches() = %d, want 2", got)
+	}
+}
+
+func TestAPISearchResult(t *testing.T) {
+	api := &APIEntry{
+		Name:        "card.voltage",
+		Description: "Provides the current V+ voltage level. When used with mode, configures thresholds.",
+		Properties: map[string]APIProperty{
+			"mode":  {Description: "Sets thresholds.", SubDescriptions: []PropertySubDescription{{Const: "lipo", Description: "For LiPo batteries."}}},
+			"hours": {Description: "The number of hours to analyze."},
+		},
+	}
+	result := apiSearchResult(api, searchTerms("battery"), 1.23456)
+	if result.Summary != "Provides the current V+ voltage level." || result.Score != 1.235 {
+		t.Errorf("apiSearchResult() = %#v", result)
+	}
+	if !reflect.DeepEqual(result.MatchingProperties, []string{"mode"}) {
+		t.Errorf("apiSearchResult() matching properties = %v, want [mode]", result.MatchingProperties)
+	}
+}
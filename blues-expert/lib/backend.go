@@ -0,0 +1,253 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultSearchBackends is the default docs_search backend chain: the Blues documentation
+	// search API, falling back to the local index
+	DefaultSearchBackends = "ragpi,local"
+	// searchBackendTimeout is the timeout for requests to remote search backends
+	searchBackendTimeout = 30 * time.Second
+	// openSearchResultSize is the number of results requested from OpenSearch-style backends
+	openSearchResultSize = 5
+)
+
+// SearchBackend is a source of documentation search results for docs_search
+type SearchBackend interface {
+	// Name identifies the backend in logs and messages
+	Name() string
+	// Search returns the results for a query, or an error if the backend is unavailable
+	Search(ctx context.Context, request *mcp.CallToolRequest, query string) ([]SearchResult, error)
+}
+
+// chainBackend searches each backend in turn until one is available
+type chainBackend []SearchBackend
+
+// Name lists the backends of the chain, e.g. "ragpi,local"
+func (c chainBackend) Name() string {
+	names := make([]string, 0, len(c))
+	for _, backend := range c {
+		names = append(names, backend.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Search returns the results of the first backend that does not fail. An empty result is an answer,
+// so it does not fall through to the next backend.
+func (c chainBackend) Search(ctx context.Context, request *mcp.CallToolRequest, query string) ([]SearchResult, error) {
+	var errs []error
+	for i, backend := range c {
+		results, err := backend.Search(ctx, request, query)
+		if err == nil {
+			return results, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %v", backend.Name(), err))
+
+		if i < len(c)-1 {
+			next := c[i+1].Name()
+			log.Warn().Err(err).Str("backend", backend.Name()).Str("next", next).Msg("Search backend unavailable, trying next backend")
+			if request != nil && request.Session != nil {
+				request.Session.Log(ctx, &mcp.LoggingMessageParams{
+					Level: "warning",
+					Data:  fmt.Sprintf("The %s documentation search is unavailable (%v), trying %s instead", backend.Name(), err, next),
+				})
+			}
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+var (
+	searchBackend      SearchBackend
+	searchBackendMutex sync.RWMutex
+)
+
+// SetSearchBackends configures the docs_search backends from a comma-separated chain of
+// "kind" or "kind=url" entries, tried in order. Supported kinds are:
+//   - ragpi: the Blues documentation search API, or a ragpi mirror at url
+//   - opensearch=url: an OpenSearch/Elasticsearch-style _search endpoint
+//   - local: the index of embedded documentation and Notecard API schema
+func SetSearchBackends(spec string) error {
+	backend, err := ParseSearchBackends(spec)
+	if err != nil {
+		return err
+	}
+	searchBackendMutex.Lock()
+	searchBackend = backend
+	searchBackendMutex.Unlock()
+	return nil
+}
+
+// ParseSearchBackends parses a backend chain in the format accepted by SetSearchBackends
+func ParseSearchBackends(spec string) (SearchBackend, error) {
+	var chain chainBackend
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, endpoint, _ := strings.Cut(entry, "=")
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		endpoint = strings.TrimSpace(endpoint)
+		if endpoint != "" {
+			if parsed, err := url.Parse(endpoint); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				return nil, fmt.Errorf("invalid URL '%s' for search backend '%s'", endpoint, kind)
+			}
+		}
+
+		switch kind {
+		case "ragpi":
+			if endpoint == "" {
+				endpoint = BluesDocsAPIBaseURL
+			}
+			chain = append(chain, &ragpiBackend{baseURL: endpoint})
+		case "opensearch":
+			if endpoint == "" {
+				return nil, fmt.Errorf("search backend 'opensearch' requires a URL, e.g. opensearch=http://localhost:9200/docs/_search")
+			}
+			chain = append(chain, &openSearchBackend{searchURL: endpoint})
+		case "local":
+			if endpoint != "" {
+				return nil, fmt.Errorf("search backend 'local' does not take a URL")
+			}
+			chain = append(chain, localBackend{})
+		default:
+			return nil, fmt.Errorf("unknown search backend '%s'. Valid backends are: ragpi, opensearch, local", kind)
+		}
+	}
+
+	switch len(chain) {
+	case 0:
+		return nil, fmt.Errorf("no search backends configured")
+	case 1:
+		return chain[0], nil
+	}
+	return chain, nil
+}
+
+// getSearchBackend returns the configured search backend, or the default chain
+func getSearchBackend() SearchBackend {
+	searchBackendMutex.RLock()
+	backend := searchBackend
+	searchBackendMutex.RUnlock()
+	if backend != nil {
+		return backend
+	}
+
+	backend, _ = ParseSearchBackends(DefaultSearchBackends)
+	return backend
+}
+
+// localBackend searches the local index of embedded documentation and Notecard API schema
+type localBackend struct{}
+
+// Name identifies the local backend
+func (localBackend) Name() string {
+	return "local"
+}
+
+// Search searches the local index
+func (localBackend) Search(ctx context.Context, request *mcp.CallToolRequest, query string) ([]SearchResult, error) {
+	return SearchLocalDocs(query), nil
+}
+
+// openSearchBackend searches an OpenSearch/Elasticsearch-style _search endpoint whose documents
+// have "title", "content" and "url" fields. Credentials may be given in the URL.
+type openSearchBackend struct {
+	searchURL string
+}
+
+// openSearchResponse is the subset of an OpenSearch search response used for results
+type openSearchResponse struct {
+	Hits struct {
+		Hits []struct {
+			ID     string  `json:"_id"`
+			Score  float64 `json:"_score"`
+			Source struct {
+				Title     string `json:"title"`
+				Content   string `json:"content"`
+				URL       string `json:"url"`
+				CreatedAt string `json:"created_at"`
+			} `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// Name identifies the OpenSearch backend
+func (b *openSearchBackend) Name() string {
+	return "opensearch"
+}
+
+// Search runs a multi_match query against the title and content fields
+func (b *openSearchBackend) Search(ctx context.Context, request *mcp.CallToolRequest, query string) ([]SearchResult, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"size": openSearchResultSize,
+		"query": map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  query,
+				"fields": []string{"title^2", "content"},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build search query: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", b.searchURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: searchBackendTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make search request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		errorMsg := fmt.Sprintf("search API returned status %d", resp.StatusCode)
+		if len(data) > 0 {
+			errorMsg += fmt.Sprintf(": %s", string(data))
+		}
+		return nil, errors.New(errorMsg)
+	}
+
+	var response openSearchResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse search response: %v", err)
+	}
+
+	results := make([]SearchResult, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		results = append(results, SearchResult{
+			ID:        hit.ID,
+			Title:     hit.Source.Title,
+			Content:   hit.Source.Content,
+			URL:       hit.Source.URL,
+			CreatedAt: hit.Source.CreatedAt,
+			Score:     hit.Score,
+		})
+	}
+	return results, nil
+}
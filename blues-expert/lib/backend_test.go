@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRagpiBackend(t *testing.T) {
+	t.Setenv("BLUES_DOCS_API_KEY", "test-key")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") != "sleep" || r.Header.Get("x-api-key") != "test-key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode([]SearchResult{{ID: "1", Title: "Sleep", Content: "Sleep the host.", URL: "https://example.com/sleep"}})
+	}))
+	defer server.Close()
+
+	backend, err := ParseSearchBackends("ragpi=" + server.URL)
+	if err != nil {
+		t.Fatalf("ParseSearchBackends() error = %v", err)
+	}
+	results, err := backend.Search(context.Background(), nil, "sleep")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	want := []SearchResult{{ID: "1", Title: "Sleep", Content: "Sleep the host.", URL: "https://example.com/sleep"}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Search() = %v, want %v", results, want)
+	}
+}
+
+func TestOpenSearchBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query struct {
+				MultiMatch struct {
+					Query string `json:"query"`
+				} `json:"multi_match"`
+			} `json:"query"`
+		}
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil || body.Query.MultiMatch.Query != "gps" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"hits":{"hits":[{"_id":"a","_source":{"title":"GPS","content":"Use card.location.mode.","url":"https://example.com/gps"}}]}}`))
+	}))
+	defer server.Close()
+
+	backend, err := ParseSearchBackends("opensearch=" + server.URL)
+	if err != nil {
+		t.Fatalf("ParseSearchBackends() error = %v", err)
+	}
+	results, err := backend.Search(context.Background(), nil, "gps")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	want := []SearchResult{{ID: "a", Title: "GPS", Content: "Use card.location.mode.", URL: "https://example.com/gps"}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Search() = %v, want %v", results, want)
+	}
+}
+
+func TestChainBackendFallsThrough(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer working.Close()
+
+	backend, err := ParseSearchBackends("opensearch=" + failing.URL + ", ragpi=" + working.URL + ", local")
+	if err != nil {
+		t.Fatalf("ParseSearchBackends() error = %v", err)
+	}
+	if name := backend.Name(); name != "opensearch,ragpi,local" {
+		t.Errorf("Name() = %q", name)
+	}
+
+	// An empty result from the second backend is an answer, so the local index is not searched
+	results, err := backend.Search(context.Background(), nil, "anything")
+	if err != nil || len(results) != 0 {
+		t.Errorf("Search() = %v, %v, want no results and no error", results, err)
+	}
+
+	backend, _ = ParseSearchBackends("opensearch=" + failing.URL)
+	if _, err := backend.Search(context.Background(), nil, "anything"); err == nil {
+		t.Error("Search() expected an error when every backend fails")
+	}
+}
+
+func TestParseSearchBackendsErrors(t *testing.T) {
+	for _, spec := range []string{"", "bing", "opensearch", "local=http://x", "ragpi=not-a-url"} {
+		if _, err := ParseSearchBackends(spec); err == nil {
+			t.Errorf("ParseSearchBackends(%q) expected an error", spec)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package lib
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DocEntry describes a firmware document in the documentation catalog
+type DocEntry struct {
+	SDK          string   `json:"sdk"`
+	DocumentType string   `json:"document_type"`
+	Title        string   `json:"title"`
+	Summary      string   `json:"summary,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	URI          string   `json:"uri"`
+	Size         int64    `json:"-"`
+}
+
+// docFrontMatter is the metadata at the top of a firmware document, between "---" lines
+type docFrontMatter struct {
+	Title   string
+	Summary string
+	Tags    []string
+}
+
+// docURI returns the resource URI of a document, e.g. "blues://docs/arduino/power_management"
+func docURI(sdk string, documentType string) string {
+	return DocsResourcePrefix + sdk + "/" + documentType
+}
+
+// ListDocs returns the catalog of firmware documents, sorted by SDK and document type
+func ListDocs() []DocEntry {
+	library := docFiles()
+	var entries []DocEntry
+	for _, key := range docKeys(library) {
+		sdk, documentType, _ := strings.Cut(key, "/")
+		frontMatter, body := parseFrontMatter(library[key])
+		title := frontMatter.Title
+		if title == "" {
+			title = markdownTitle(body, documentType)
+		}
+		entries = append(entries, DocEntry{
+			SDK:          sdk,
+			DocumentType: documentType,
+			Title:        title,
+			Summary:      frontMatter.Summary,
+			Tags:         frontMatter.Tags,
+			URI:          docURI(sdk, documentType),
+			Size:         int64(len(body)),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].URI < entries[j].URI
+	})
+	return entries
+}
+
+// ListDocSDKs returns the SDKs with firmware documentation, e.g. "arduino"
+func ListDocSDKs() []string {
+	var sdks []string
+	for _, doc := range ListDocs() {
+		if !slices.Contains(sdks, doc.SDK) {
+			sdks = append(sdks, doc.SDK)
+		}
+	}
+	return sdks
+}
+
+// ListDocTypes returns the document types available for an SDK, e.g. "power_management", or the
+// document types of every SDK if sdk is empty
+func ListDocTypes(sdk string) []string {
+	var documentTypes []string
+	for _, doc := range ListDocs() {
+		if (sdk == "" || doc.SDK == strings.ToLower(sdk)) && !slices.Contains(documentTypes, doc.DocumentType) {
+			documentTypes = append(documentTypes, doc.DocumentType)
+		}
+	}
+	sort.Strings(documentTypes)
+	return documentTypes
+}
+
+// parseFrontMatter splits a document into its front matter and Markdown body. Documents without
+// front matter are returned unchanged.
+func parseFrontMatter(content string) (docFrontMatter, string) {
+	var frontMatter docFrontMatter
+	rest, ok := strings.CutPrefix(content, "---\n")
+	if !ok {
+		return frontMatter, content
+	}
+	header, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		return frontMatter, content
+	}
+
+	for _, line := range strings.Split(header, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "title":
+			frontMatter.Title = value
+		case "summary":
+			frontMatter.Summary = value
+		case "tags":
+			for _, tag := range strings.Split(strings.Trim(value, "[]"), ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					frontMatter.Tags = append(frontMatter.Tags, tag)
+				}
+			}
+		}
+	}
+	return frontMatter, strings.TrimLeft(body, "\n")
+}
+
+// markdownTitle returns the first level 1 heading of a document, or fallback if it has none
+func markdownTitle(content string, fallback string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		}
+	}
+	return fallback
+}
+
+// readDoc reads the Markdown body of a firmware document, without its front matter
+func readDoc(sdk string, documentType string) ([]byte, error) {
+	sdk = strings.ToLower(sdk)
+	if !isDocPathElement(sdk) || !isDocPathElement(documentType) {
+		return nil, fmt.Errorf("invalid SDK '%s' or document type '%s'", sdk, documentType)
+	}
+	data, ok := docFiles()[sdk+"/"+documentType]
+	if !ok {
+		return nil, fmt.Errorf("document %s/%s: %w", sdk, documentType, fs.ErrNotExist)
+	}
+	_, body := parseFrontMatter(data)
+	return []byte(body), nil
+}
+
+// firmwareDocOutput returns the structured output of the firmware tools for a document
+func firmwareDocOutput(sdk string, documentType string, content string) *FirmwareDocOutput {
+	output := &FirmwareDocOutput{
+		SDK:          sdk,
+		DocumentType: documentType,
+		Title:        documentType,
+		URI:          docURI(sdk, documentType),
+		Content:      content,
+	}
+	for _, doc := range ListDocs() {
+		if doc.SDK == sdk && doc.DocumentType == documentType {
+			output.Title = doc.Title
+		}
+	}
+	return output
+}
+
+// isDocPathElement reports whether a URI or argument value names a single documentation path element
+func isDocPathElement(value string) bool {
+	return value != "" && value != "." && value != ".." && !strings.ContainsAny(value, `/\`)
+}
+
+// docNotFoundError describes a missing firmware document, listing the documents that are available
+func docNotFoundError(sdk string, documentType string) error {
+	sdk = strings.ToLower(sdk)
+	sdks := ListDocSDKs()
+	if !slices.Contains(sdks, sdk) {
+		if suggestion := suggestClosest(sdk, sdks); suggestion != "" {
+			return fmt.Errorf("no documentation for SDK '%s' — did you mean '%s'? Valid SDKs are: %s", sdk, suggestion, strings.Join(sdks, ", "))
+		}
+		return fmt.Errorf("no documentation for SDK '%s'. Valid SDKs are: %s", sdk, strings.Join(sdks, ", "))
+	}
+
+	var available []string
+	var lines []string
+	for _, doc := range ListDocs() {
+		if doc.SDK != sdk {
+			continue
+		}
+		available = append(available, doc.DocumentType)
+		line := fmt.Sprintf("- %s: %s", doc.DocumentType, doc.Title)
+		if doc.Summary != "" {
+			line += " — " + doc.Summary
+		}
+		lines = append(lines, line)
+	}
+
+	message := fmt.Sprintf("document_type '%s' is not available for the %s SDK", documentType, sdk)
+	if suggestion := suggestClosest(documentType, available); suggestion != "" {
+		message += fmt.Sprintf(" — did you mean '%s'?", suggestion)
+	}
+	return fmt.Errorf("%s. Available documents:\n%s", message, strings.Join(lines, "\n"))
+}
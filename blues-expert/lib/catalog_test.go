@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	content := "---\ntitle: Power\nsummary: Sleep the host: save power.\ntags: [power, card.attn]\n---\n\n# Power\n"
+	frontMatter, body := parseFrontMatter(content)
+	want := docFrontMatter{Title: "Power", Summary: "Sleep the host: save power.", Tags: []string{"power", "card.attn"}}
+	if !reflect.DeepEqual(frontMatter, want) {
+		t.Errorf("parseFrontMatter() = %#v, want %#v", frontMatter, want)
+	}
+	if body != "# Power\n" {
+		t.Errorf("parseFrontMatter() body = %q", body)
+	}
+
+	if frontMatter, body := parseFrontMatter("# No front matter\n---\n"); !reflect.DeepEqual(frontMatter, docFrontMatter{}) || body != "# No front matter\n---\n" {
+		t.Errorf("parseFrontMatter() changed a document without front matter: %#v, %q", frontMatter, body)
+	}
+}
+
+func TestListDocs(t *testing.T) {
+	for _, doc := range ListDocs() {
+		if doc.Title == "" || doc.Summary == "" || len(doc.Tags) == 0 {
+			t.Errorf("document %s is missing front matter: %#v", doc.URI, doc)
+		}
+		content, err := readDoc(doc.SDK, doc.DocumentType)
+		if err != nil {
+			t.Errorf("readDoc(%s, %s) error = %v", doc.SDK, doc.DocumentType, err)
+		}
+		if strings.HasPrefix(string(content), "---") {
+			t.Errorf("readDoc(%s, %s) returned front matter", doc.SDK, doc.DocumentType)
+		}
+	}
+}
+
+func TestDocNotFoundError(t *testing.T) {
+	message := docNotFoundError("C", "best_practices").Error()
+	if !strings.Contains(message, "not available for the c SDK") || !strings.Contains(message, "- index: Getting Started with note-c") {
+		t.Errorf("docNotFoundError() = %q", message)
+	}
+
+	message = docNotFoundError("arduino", "templtes").Error()
+	if !strings.Contains(message, "did you mean 'templates'?") {
+		t.Errorf("docNotFoundError() = %q", message)
+	}
+
+	message = docNotFoundError("pyton", "index").Error()
+	if !strings.Contains(message, "did you mean 'python'?") {
+		t.Errorf("docNotFoundError() = %q", message)
+	}
+}
@@ -0,0 +1,61 @@
+package lib
+
+import (
+	"context"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rs/zerolog/log"
+)
+
+// maxCompletionValues is the maximum number of values in a completion response, as set by the MCP specification
+const maxCompletionValues = 100
+
+// HandleCompletion suggests values for prompt and resource template arguments: SDKs, the document
+// types available for an SDK, and the Notecard API names in the cached schema
+func HandleCompletion(ctx context.Context, request *mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	params := request.Params
+	var contextArgs map[string]string
+	if params.Context != nil {
+		contextArgs = params.Context.Arguments
+	}
+
+	var candidates []string
+	switch params.Argument.Name {
+	case "sdk":
+		candidates = ListDocSDKs()
+	case "document_type":
+		candidates = ListDocTypes(contextArgs["sdk"])
+	case "name":
+		// Only the Notecard API resource template has a "name" argument
+		if params.Ref == nil || !strings.HasPrefix(params.Ref.URI, APIResourcePrefix) {
+			break
+		}
+		names, err := ListAPIResourceNames()
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to list Notecard APIs for completion")
+			break
+		}
+		candidates = names
+	}
+
+	return &mcp.CompleteResult{Completion: completionValues(candidates, params.Argument.Value)}, nil
+}
+
+// completionValues returns the candidates starting with the partial value, ignoring case
+func completionValues(candidates []string, value string) mcp.CompletionResultDetails {
+	prefix := strings.ToLower(value)
+	matches := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	details := mcp.CompletionResultDetails{Values: matches, Total: len(matches)}
+	if len(matches) > maxCompletionValues {
+		details.Values = matches[:maxCompletionValues]
+		details.HasMore = true
+	}
+	return details
+}
@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestHandleCompletion(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *mcp.CompleteParams
+		want   []string
+	}{
+		{
+			name: "sdk for a prompt",
+			params: &mcp.CompleteParams{
+				Ref:      &mcp.CompleteReference{Type: "ref/prompt", Name: "reduce_power"},
+				Argument: mcp.CompleteParamsArgument{Name: "sdk", Value: "P"},
+			},
+			want: []string{"python"},
+		},
+		{
+			name: "document types of the selected sdk",
+			params: &mcp.CompleteParams{
+				Ref:      &mcp.CompleteReference{Type: "ref/resource", URI: "blues://docs/{sdk}/{document_type}"},
+				Argument: mcp.CompleteParamsArgument{Name: "document_type", Value: ""},
+				Context:  &mcp.CompleteContext{Arguments: map[string]string{"sdk": "python"}},
+			},
+			want: []string{"best_practices", "index"},
+		},
+		{
+			name: "document types of every sdk",
+			params: &mcp.CompleteParams{
+				Ref:      &mcp.CompleteReference{Type: "ref/resource", URI: "blues://docs/{sdk}/{document_type}"},
+				Argument: mcp.CompleteParamsArgument{Name: "document_type", Value: "s"},
+			},
+			want: []string{"sensors"},
+		},
+		{
+			name: "tool arguments are not completed",
+			params: &mcp.CompleteParams{
+				Ref:      &mcp.CompleteReference{Type: "ref/prompt", Name: "debug_sync"},
+				Argument: mcp.CompleteParamsArgument{Name: "api", Value: "card."},
+			},
+			want: []string{},
+		},
+		{
+			name: "unknown argument",
+			params: &mcp.CompleteParams{
+				Ref:      &mcp.CompleteReference{Type: "ref/prompt", Name: "new_sensor_project"},
+				Argument: mcp.CompleteParamsArgument{Name: "sensor", Value: "bme"},
+			},
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := HandleCompletion(context.Background(), &mcp.CompleteRequest{Params: tt.params})
+			if err != nil {
+				t.Fatalf("HandleCompletion() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Completion.Values, tt.want) {
+				t.Errorf("HandleCompletion() = %v, want %v", result.Completion.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletionValuesLimit(t *testing.T) {
+	candidates := make([]string, maxCompletionValues+5)
+	for i := range candidates {
+		candidates[i] = "card.x"
+	}
+	details := completionValues(candidates, "card")
+	if len(details.Values) != maxCompletionValues || !details.HasMore || details.Total != len(candidates) {
+		t.Errorf("completionValues() = %d values, hasMore %v, total %d", len(details.Values), details.HasMore, details.Total)
+	}
+}
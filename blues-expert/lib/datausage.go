@@ -0,0 +1,289 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// minutesPerMonth is the length of the month data usage is estimated over, 30 days
+const minutesPerMonth = 30 * 24 * 60
+
+// defaultSessionOverheadBytes is the assumed cost of opening a Notehub session (network attach, TLS
+// and the sync handshake), used when no measured overhead is given
+const defaultSessionOverheadBytes = 2048
+
+// DataUsageNotefile describes a Notefile whose Notes are synced to Notehub
+type DataUsageNotefile struct {
+	File         string          `json:"file,omitempty" jsonschema:"The Notefile name, e.g. 'readings.qo'. Defaults to 'data.qo'"`
+	Template     json.RawMessage `json:"template,omitempty" jsonschema:"The template of the Notefile: a note.template request or a template body"`
+	Note         json.RawMessage `json:"note,omitempty" jsonschema:"A sample Note: a note.add request or a Note body"`
+	NotesPerHour float64         `json:"notes_per_hour" jsonschema:"The number of Notes added to the Notefile per hour"`
+}
+
+// NotefileUsage is the estimated data usage of a Notefile
+type NotefileUsage struct {
+	File          string  `json:"file" jsonschema:"The Notefile name"`
+	Templated     bool    `json:"templated" jsonschema:"Whether the Notefile has a template"`
+	NoteBytes     int     `json:"note_bytes" jsonschema:"The estimated bytes per Note before compression"`
+	NotesPerHour  float64 `json:"notes_per_hour" jsonschema:"The number of Notes added per hour"`
+	NotesPerSync  float64 `json:"notes_per_sync,omitempty" jsonschema:"The number of Notes sent in each periodic outbound sync"`
+	BytesPerMonth int     `json:"bytes_per_month" jsonschema:"The Note bytes sent per month"`
+}
+
+// DataUsageEstimate is the estimated cellular data usage of a Notecard
+type DataUsageEstimate struct {
+	Valid                bool              `json:"valid" jsonschema:"Whether every input request is valid, so that data usage was estimated"`
+	Requests             []BatchItemResult `json:"requests,omitempty" jsonschema:"The validation result of each input request"`
+	Mode                 string            `json:"mode,omitempty" jsonschema:"The hub.set sync mode"`
+	OutboundMinutes      int               `json:"outbound_minutes,omitempty" jsonschema:"The periodic outbound sync interval in minutes"`
+	InboundMinutes       int               `json:"inbound_minutes,omitempty" jsonschema:"The periodic inbound sync interval in minutes"`
+	SessionsPerMonth     float64           `json:"sessions_per_month" jsonschema:"The estimated number of Notehub sessions per month"`
+	SessionOverheadBytes int               `json:"session_overhead_bytes" jsonschema:"The bytes assumed for opening each Notehub session"`
+	Notefiles            []NotefileUsage   `json:"notefiles,omitempty" jsonschema:"The estimated data usage of each Notefile"`
+	BytesPerSync         int               `json:"bytes_per_sync" jsonschema:"The estimated bytes of each periodic outbound sync, including the session overhead unless it uses an open continuous session"`
+	BytesPerMonth        int               `json:"bytes_per_month" jsonschema:"The estimated bytes per month, including the session overhead"`
+	Warnings             []string          `json:"warnings,omitempty" jsonschema:"Assumptions and settings that affect the estimate"`
+}
+
+// EstimateDataUsage estimates the bytes per sync and per month a Notecard uses with the given
+// hub.set request and Notefiles. Every input request is validated first; if any is invalid the
+// estimate only holds the validation results. An error is only returned if the input as a whole
+// cannot be processed.
+func EstimateDataUsage(hubSet string, notefiles string, sessionOverheadBytes int, schemaURL string) (*DataUsageEstimate, error) {
+	var files []DataUsageNotefile
+	if strings.TrimSpace(notefiles) != "" {
+		if err := json.Unmarshal([]byte(notefiles), &files); err != nil {
+			return nil, fmt.Errorf("invalid notefiles JSON array: %v", err)
+		}
+	}
+	if sessionOverheadBytes <= 0 {
+		sessionOverheadBytes = defaultSessionOverheadBytes
+	}
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+
+	estimate := &DataUsageEstimate{SessionOverheadBytes: sessionOverheadBytes}
+	validate := func(data json.RawMessage) map[string]interface{} {
+		itemResult := BatchItemResult{Index: len(estimate.Requests)}
+		reqMap := validateRawRequest(&itemResult, data, schemaURL)
+		estimate.Requests = append(estimate.Requests, itemResult)
+		return reqMap
+	}
+
+	hub := validate(json.RawMessage(hubSet))
+	if hub != nil && !isRequestFor(hub, "hub.set") {
+		estimate.Requests[0].Valid = false
+		estimate.Requests[0].Error = "expected a hub.set request"
+	}
+
+	// Validate the template and sample Note of each Notefile as the requests the firmware makes
+	requests := make([]struct{ template, note map[string]interface{} }, len(files))
+	for i, file := range files {
+		if file.File == "" {
+			files[i].File = "data.qo"
+		}
+		if len(file.Template) == 0 && len(file.Note) == 0 {
+			return nil, fmt.Errorf("notefile %s needs a template or a sample note", files[i].File)
+		}
+		if len(file.Template) > 0 {
+			requests[i].template = validate(notefileRequest(file.Template, "note.template", files[i].File))
+		}
+		if len(file.Note) > 0 {
+			requests[i].note = validate(notefileRequest(file.Note, "note.add", files[i].File))
+		}
+	}
+
+	for _, itemResult := range estimate.Requests {
+		if !itemResult.Valid {
+			return estimate, nil
+		}
+	}
+	estimate.Valid = true
+
+	schedule := newHubSchedule(hub)
+	estimate.Mode, estimate.OutboundMinutes, estimate.InboundMinutes = schedule.mode, schedule.outboundMinutes, schedule.inboundMinutes
+	estimate.Warnings = append(estimate.Warnings, schedule.warnings...)
+	sessions, syncOverheadBytes := schedule.sessions, 0
+	if schedule.syncsOpenSessions {
+		syncOverheadBytes = sessionOverheadBytes
+	}
+
+	noteBytesPerSync := 0.0
+	noteBytesPerMonth := 0
+	for i, file := range files {
+		usage, warnings := notefileUsage(file, requests[i].template, requests[i].note)
+		estimate.Warnings = append(estimate.Warnings, warnings...)
+		if schedule.syncing {
+			if sync, _ := requests[i].note["sync"].(bool); sync && schedule.mode != "continuous" {
+				sessions += file.NotesPerHour * minutesPerMonth / 60
+				estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("%s: sync:true opens a session for every Note", file.File))
+			}
+			if schedule.outboundSyncs > 0 {
+				usage.NotesPerSync = roundTo(file.NotesPerHour*float64(estimate.OutboundMinutes)/60, 2)
+				noteBytesPerSync += usage.NotesPerSync * float64(usage.NoteBytes)
+			}
+		} else {
+			usage.BytesPerMonth = 0
+		}
+		noteBytesPerMonth += usage.BytesPerMonth
+		estimate.Notefiles = append(estimate.Notefiles, usage)
+	}
+
+	estimate.SessionsPerMonth = roundTo(sessions, 1)
+	if schedule.outboundSyncs > 0 {
+		estimate.BytesPerSync = int(math.Round(noteBytesPerSync)) + syncOverheadBytes
+	}
+	estimate.BytesPerMonth = noteBytesPerMonth + int(math.Round(sessions*float64(sessionOverheadBytes)))
+	estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("sizes are before compression and assume %d bytes of overhead per session; measure a session on your network and pass session_overhead_bytes for a closer estimate", sessionOverheadBytes))
+	return estimate, nil
+}
+
+// hubSchedule is the sync schedule set by a hub.set request
+type hubSchedule struct {
+	mode              string
+	outboundMinutes   int
+	inboundMinutes    int
+	outboundSyncs     float64 // periodic outbound syncs per month
+	sessions          float64 // Notehub sessions per month
+	syncing           bool    // whether Notes are synced at all
+	syncsOpenSessions bool    // whether each sync opens a new session
+	warnings          []string
+}
+
+// newHubSchedule returns the sync schedule set by a validated hub.set request
+func newHubSchedule(hub map[string]interface{}) hubSchedule {
+	schedule := hubSchedule{syncing: true, syncsOpenSessions: true}
+	schedule.mode, _ = hub["mode"].(string)
+	if schedule.mode == "" {
+		schedule.mode = "periodic"
+	}
+	schedule.outboundMinutes = intValue(hub["outbound"])
+	schedule.inboundMinutes = intValue(hub["inbound"])
+	if _, ok := hub["voutbound"]; ok {
+		schedule.warnings = append(schedule.warnings, "voutbound overrides outbound with a voltage-variable cadence, which is not modeled; the outbound interval is used")
+	}
+	if _, ok := hub["vinbound"]; ok {
+		schedule.warnings = append(schedule.warnings, "vinbound overrides inbound with a voltage-variable cadence, which is not modeled; the inbound interval is used")
+	}
+
+	inboundSyncs := 0.0
+	switch schedule.mode {
+	case "periodic", "continuous":
+		if schedule.outboundMinutes > 0 {
+			schedule.outboundSyncs = minutesPerMonth / float64(schedule.outboundMinutes)
+		} else {
+			schedule.warnings = append(schedule.warnings, fmt.Sprintf("outbound is not set, so in %s mode outbound Notes only sync on explicit syncs (hub.sync or sync:true)", schedule.mode))
+		}
+		if schedule.inboundMinutes > 0 {
+			inboundSyncs = minutesPerMonth / float64(schedule.inboundMinutes)
+		}
+	case "minimum":
+		schedule.warnings = append(schedule.warnings, "minimum mode disables periodic syncs, so Notes only sync on explicit syncs (hub.sync or sync:true)")
+	default:
+		schedule.syncing = false
+		schedule.warnings = append(schedule.warnings, fmt.Sprintf("%s mode does not sync Notes with Notehub", schedule.mode))
+	}
+
+	// Outbound syncs also check for inbound changes, so the busier cadence sets the sessions
+	schedule.sessions = math.Max(schedule.outboundSyncs, inboundSyncs)
+	if schedule.mode == "continuous" {
+		if duration := intValue(hub["duration"]); duration > 0 {
+			// Syncs use the open session, which is renewed every duration
+			schedule.sessions, schedule.syncsOpenSessions = minutesPerMonth/float64(duration), false
+		} else {
+			schedule.warnings = append(schedule.warnings, "duration is not set, so each sync of the continuous session is counted as a new session, an upper bound")
+		}
+	}
+	return schedule
+}
+
+// notefileRequest returns a Notefile template or sample Note as the request that creates it, wrapping
+// a bare body in a request of the given type
+func notefileRequest(data json.RawMessage, requestType string, file string) json.RawMessage {
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil || isRequestFor(object, requestType) {
+		return data
+	}
+	request, err := json.Marshal(map[string]interface{}{"req": requestType, "file": file, "body": object})
+	if err != nil {
+		return data
+	}
+	return request
+}
+
+// notefileUsage sizes the Notes of a Notefile from its template, or from its sample Note when it has
+// no template
+func notefileUsage(file DataUsageNotefile, template map[string]interface{}, note map[string]interface{}) (NotefileUsage, []string) {
+	usage := NotefileUsage{File: file.File, Templated: template != nil, NotesPerHour: file.NotesPerHour}
+	var warnings []string
+
+	if template != nil {
+		noteJSON := ""
+		if note != nil {
+			noteJSON = string(file.Note)
+		}
+		templateJSON, _ := json.Marshal(template)
+		result, err := CheckNoteTemplate(string(templateJSON), noteJSON)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", file.File, err))
+		} else {
+			usage.NoteBytes = result.NoteBytes
+			for _, finding := range result.Errors {
+				warnings = append(warnings, fmt.Sprintf("%s: %s: %s", file.File, finding.Pointer, finding.Message))
+			}
+		}
+	} else {
+		// Untemplated Notes are sent as JSON, with the same metadata as templated Notes
+		usage.NoteBytes = templateMetadataBytes
+		if body, ok := note["body"]; ok {
+			data, _ := json.Marshal(body)
+			usage.NoteBytes += len(data)
+		}
+		payload, _ := note["payload"].(string)
+		usage.NoteBytes += len(payload)
+		warnings = append(warnings, fmt.Sprintf("%s: untemplated Notes are sent as JSON; a template would make them smaller", file.File))
+	}
+
+	usage.BytesPerMonth = int(math.Round(file.NotesPerHour * minutesPerMonth / 60 * float64(usage.NoteBytes)))
+	return usage, warnings
+}
+
+// intValue returns a JSON number as an int, or 0 if it is not a number
+func intValue(value interface{}) int {
+	number, _ := value.(float64)
+	return int(number)
+}
+
+// roundTo rounds a value to the given number of decimal places
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
+
+// formatDataUsageEstimate summarizes a data usage estimate
+func formatDataUsageEstimate(estimate *DataUsageEstimate) string {
+	if !estimate.Valid {
+		invalid := 0
+		for _, itemResult := range estimate.Requests {
+			if !itemResult.Valid {
+				invalid++
+			}
+		}
+		return fmt.Sprintf("Data usage was not estimated: %d of %d input request(s) are invalid.", invalid, len(estimate.Requests))
+	}
+	return fmt.Sprintf("Estimated data usage in %s mode: %s per month over %.1f session(s), %s per outbound sync.", estimate.Mode, formatBytes(estimate.BytesPerMonth), estimate.SessionsPerMonth, formatBytes(estimate.BytesPerSync))
+}
+
+// formatBytes formats a byte count for reading, e.g. "1.5 MB"
+func formatBytes(count int) string {
+	switch {
+	case count >= 1000*1000:
+		return fmt.Sprintf("%.2f MB", float64(count)/(1000*1000))
+	case count >= 1000:
+		return fmt.Sprintf("%.1f KB", float64(count)/1000)
+	}
+	return fmt.Sprintf("%d bytes", count)
+}
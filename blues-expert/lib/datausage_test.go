@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateDataUsage(t *testing.T) {
+	hubSet := `{"req":"hub.set","mode":"periodic","outbound":60,"inbound":720}`
+	notefiles := `[
+		{"file":"readings.qo","template":{"new_vals":true,"temperature":14.1,"humidity":11,"pump_state":"4"},"notes_per_hour":4},
+		{"file":"alerts.qo","note":{"temp":21.5},"notes_per_hour":0.5}
+	]`
+	estimate, err := EstimateDataUsage(hubSet, notefiles, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !estimate.Valid || len(estimate.Requests) != 3 {
+		t.Fatalf("EstimateDataUsage() = %+v", estimate)
+	}
+
+	// 720 hourly outbound syncs, each sending 4 40-byte readings and half a 43-byte alert
+	readings, alerts := estimate.Notefiles[0], estimate.Notefiles[1]
+	if !readings.Templated || readings.NoteBytes != 40 || readings.NotesPerSync != 4 || readings.BytesPerMonth != 4*720*40 {
+		t.Errorf("EstimateDataUsage() readings = %+v", readings)
+	}
+	if alerts.Templated || alerts.NoteBytes != 43 || alerts.BytesPerMonth != 360*43 {
+		t.Errorf("EstimateDataUsage() alerts = %+v", alerts)
+	}
+	if estimate.SessionsPerMonth != 720 || estimate.BytesPerSync != 160+22+2048 || estimate.BytesPerMonth != 4*720*40+360*43+720*2048 {
+		t.Errorf("EstimateDataUsage() = %+v", estimate)
+	}
+}
+
+func TestEstimateDataUsageValidatesRequests(t *testing.T) {
+	estimate, err := EstimateDataUsage(`{"req":"hub.set","mode":"sometimes"}`, `[{"file":"readings.qo","note":{"req":"note.add","body":{"temp":1}},"notes_per_hour":1}]`, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Valid || estimate.Requests[0].Valid || !estimate.Requests[1].Valid || estimate.BytesPerMonth != 0 {
+		t.Errorf("EstimateDataUsage() = %+v", estimate)
+	}
+
+	estimate, err = EstimateDataUsage(`{"req":"card.version"}`, "", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Valid || estimate.Requests[0].Error != "expected a hub.set request" {
+		t.Errorf("EstimateDataUsage() = %+v", estimate)
+	}
+
+	if _, err := EstimateDataUsage(`{"req":"hub.set"}`, `[{"file":"readings.qo"}]`, 0, ""); err == nil {
+		t.Error("EstimateDataUsage() accepted a Notefile without a template or sample Note")
+	}
+}
+
+func TestEstimateDataUsageEmptyTemplateArray(t *testing.T) {
+	estimate, err := EstimateDataUsage(`{"req":"hub.set","mode":"periodic"}`, `[{"template":{"a":[]},"note":{"a":[1]},"notes_per_hour":1}]`, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The template check reports the empty array, and the sample Note element it cannot hold
+	warnings := strings.Join(estimate.Warnings, "\n")
+	if len(estimate.Notefiles) != 1 || !strings.Contains(warnings, "data.qo: /a: arrays must contain") || !strings.Contains(warnings, "data.qo: /a/0: the template array has 0 element(s)") {
+		t.Errorf("EstimateDataUsage() = %+v", estimate)
+	}
+}
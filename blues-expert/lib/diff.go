@@ -0,0 +1,360 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SchemaDiff describes the differences between two Notecard API schema versions
+type SchemaDiff struct {
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	AddedAPIs   []string          `json:"added_apis,omitempty"`
+	RemovedAPIs []string          `json:"removed_apis,omitempty"`
+	ChangedAPIs []APIChange       `json:"changed_apis,omitempty"`
+	Affected    []AffectedRequest `json:"affected_requests,omitempty"`
+}
+
+// APIChange describes how a single API differs between two schema versions
+type APIChange struct {
+	API               string           `json:"api"`
+	Changes           []FieldChange    `json:"changes,omitempty"`
+	AddedProperties   []string         `json:"added_properties,omitempty"`
+	RemovedProperties []string         `json:"removed_properties,omitempty"`
+	RenamedProperties []PropertyRename `json:"renamed_properties,omitempty"`
+	ChangedProperties []PropertyChange `json:"changed_properties,omitempty"`
+}
+
+// PropertyRename records a property that appears to have been renamed
+type PropertyRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PropertyChange describes how a single property differs between two schema versions
+type PropertyChange struct {
+	Property string        `json:"property"`
+	Changes  []FieldChange `json:"changes"`
+}
+
+// FieldChange records the old and new value of a changed schema field
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+}
+
+// AffectedRequest describes how a schema change affects a request used by the caller
+type AffectedRequest struct {
+	Index   int      `json:"index"`
+	Request string   `json:"request"`
+	Reasons []string `json:"reasons"`
+}
+
+// DiffSchemaVersions compares two Notecard API schema versions. If requests (a JSON array or
+// newline-delimited JSON) are given, the requests affected by the differences are reported too.
+func DiffSchemaVersions(fromVersion, toVersion, apiFilter, requests string) (*SchemaDiff, error) {
+	fromURL, err := ResolveSchemaVersion(fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	toURL, err := ResolveSchemaVersion(toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	fromAPIs, err := loadAPIEntries(fromURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema version '%s': %v", schemaVersionLabel(fromVersion), err)
+	}
+	toAPIs, err := loadAPIEntries(toURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema version '%s': %v", schemaVersionLabel(toVersion), err)
+	}
+
+	diff := &SchemaDiff{
+		From: GetSchemaVersion(fromURL),
+		To:   GetSchemaVersion(toURL),
+	}
+
+	for _, name := range sortedAPINames(fromAPIs, toAPIs) {
+		if apiFilter != "" && name != apiFilter && !strings.HasPrefix(name, strings.TrimSuffix(apiFilter, ".")+".") {
+			continue
+		}
+		fromAPI, inFrom := fromAPIs[name]
+		toAPI, inTo := toAPIs[name]
+		switch {
+		case !inFrom:
+			diff.AddedAPIs = append(diff.AddedAPIs, name)
+		case !inTo:
+			diff.RemovedAPIs = append(diff.RemovedAPIs, name)
+		default:
+			if change := diffAPIEntries(fromAPI, toAPI); change != nil {
+				diff.ChangedAPIs = append(diff.ChangedAPIs, *change)
+			}
+		}
+	}
+
+	if strings.TrimSpace(requests) != "" {
+		affected, err := affectedRequests(diff, requests)
+		if err != nil {
+			return nil, err
+		}
+		diff.Affected = affected
+	}
+
+	return diff, nil
+}
+
+// schemaVersionLabel names a schema version argument for use in messages
+func schemaVersionLabel(version string) string {
+	if version == "" {
+		return "latest"
+	}
+	return version
+}
+
+// loadAPIEntries parses every request schema of a schema release
+func loadAPIEntries(schemaURL string) (map[string]*APIEntry, error) {
+	release := schemaReleaseForURL(schemaURL)
+	if err := release.init(); err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(release.dir, "*.req.notecard.api.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find schema files: %v", err)
+	}
+
+	apis := make(map[string]*APIEntry, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".req.notecard.api.json")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema for API '%s': %v", name, err)
+		}
+		var schemaData map[string]interface{}
+		if err := json.Unmarshal(data, &schemaData); err != nil {
+			return nil, fmt.Errorf("failed to parse schema for API '%s': %v", name, err)
+		}
+		apis[name] = extractAPIFromSchema(name, schemaData)
+	}
+	return apis, nil
+}
+
+// sortedAPINames returns the union of API names in two schema versions, sorted
+func sortedAPINames(a, b map[string]*APIEntry) []string {
+	var names []string
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffAPIEntries compares two versions of an API, returning nil if they are equivalent
+func diffAPIEntries(from, to *APIEntry) *APIChange {
+	change := &APIChange{API: from.Name}
+	change.Changes = appendFieldChange(change.Changes, "skus", from.SKUs, to.SKUs)
+
+	var added, removed []string
+	for name := range to.Properties {
+		if _, ok := from.Properties[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	for name := range from.Properties {
+		if _, ok := to.Properties[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	// A removed property with a matching added property of the same type is reported as a rename
+	for _, oldName := range removed {
+		newName := renamedProperty(oldName, from.Properties[oldName], added, to.Properties)
+		if newName == "" {
+			change.RemovedProperties = append(change.RemovedProperties, oldName)
+			continue
+		}
+		change.RenamedProperties = append(change.RenamedProperties, PropertyRename{From: oldName, To: newName})
+		added = removeString(added, newName)
+	}
+	change.AddedProperties = added
+
+	var common []string
+	for name := range from.Properties {
+		if _, ok := to.Properties[name]; ok {
+			common = append(common, name)
+		}
+	}
+	sort.Strings(common)
+	for _, name := range common {
+		if changes := diffAPIProperties(from.Properties[name], to.Properties[name]); len(changes) > 0 {
+			change.ChangedProperties = append(change.ChangedProperties, PropertyChange{Property: name, Changes: changes})
+		}
+	}
+
+	if len(change.Changes) == 0 && len(change.AddedProperties) == 0 && len(change.RemovedProperties) == 0 &&
+		len(change.RenamedProperties) == 0 && len(change.ChangedProperties) == 0 {
+		return nil
+	}
+	return change
+}
+
+// renamedProperty returns the added property a removed one was most likely renamed to, or ""
+func renamedProperty(oldName string, oldProp APIProperty, added []string, toProps map[string]APIProperty) string {
+	var candidates []string
+	for _, newName := range added {
+		newProp := toProps[newName]
+		if newProp.Type != oldProp.Type {
+			continue
+		}
+		if oldProp.Description != "" && newProp.Description == oldProp.Description {
+			return newName
+		}
+		candidates = append(candidates, newName)
+	}
+	return suggestClosest(oldName, candidates)
+}
+
+// diffAPIProperties compares two versions of a property
+func diffAPIProperties(from, to APIProperty) []FieldChange {
+	var changes []FieldChange
+	changes = appendFieldChange(changes, "type", from.Type, to.Type)
+	changes = appendFieldChange(changes, "enum", from.Enum, to.Enum)
+	changes = appendFieldChange(changes, "minimum", from.Minimum, to.Minimum)
+	changes = appendFieldChange(changes, "maximum", from.Maximum, to.Maximum)
+	changes = appendFieldChange(changes, "default", from.Default, to.Default)
+	changes = appendFieldChange(changes, "skus", from.SKUs, to.SKUs)
+	changes = appendFieldChange(changes, "api_version", from.APIVersion, to.APIVersion)
+
+	fromSKUs := make(map[string][]string)
+	for _, subDesc := range from.SubDescriptions {
+		fromSKUs[subDesc.Const] = subDesc.SKUs
+	}
+	for _, subDesc := range to.SubDescriptions {
+		if skus, ok := fromSKUs[subDesc.Const]; ok {
+			changes = appendFieldChange(changes, fmt.Sprintf("skus[%s]", subDesc.Const), skus, subDesc.SKUs)
+		}
+	}
+	return changes
+}
+
+// appendFieldChange appends a change if the two values differ. Lists are compared ignoring order,
+// and pointers by the value they point to.
+func appendFieldChange(changes []FieldChange, field string, from, to interface{}) []FieldChange {
+	from, to = normalizeDiffValue(from), normalizeDiffValue(to)
+	if reflect.DeepEqual(from, to) {
+		return changes
+	}
+	return append(changes, FieldChange{Field: field, From: from, To: to})
+}
+
+// normalizeDiffValue dereferences pointers, sorts string lists and maps empty values to nil
+func normalizeDiffValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case []string:
+		if len(v) == 0 {
+			return nil
+		}
+		sorted := append([]string(nil), v...)
+		sort.Strings(sorted)
+		return sorted
+	case string:
+		if v == "" {
+			return nil
+		}
+	}
+	return value
+}
+
+// removeString returns values without the first occurrence of value
+func removeString(values []string, value string) []string {
+	for i, v := range values {
+		if v == value {
+			return append(values[:i:i], values[i+1:]...)
+		}
+	}
+	return values
+}
+
+// affectedRequests reports which of the given requests use APIs, properties or values changed by the diff
+func affectedRequests(diff *SchemaDiff, input string) ([]AffectedRequest, error) {
+	items, err := splitNotecardRequests(input)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]APIChange, len(diff.ChangedAPIs))
+	for _, change := range diff.ChangedAPIs {
+		changed[change.API] = change
+	}
+
+	var affected []AffectedRequest
+	for i, item := range items {
+		var reqMap map[string]interface{}
+		if err := json.Unmarshal(item.data, &reqMap); err != nil {
+			continue
+		}
+		reqType, _ := reqMap["req"].(string)
+		if reqType == "" {
+			reqType, _ = reqMap["cmd"].(string)
+		}
+		if reqType == "" {
+			continue
+		}
+
+		var reasons []string
+		if slices.Contains(diff.RemovedAPIs, reqType) {
+			reasons = append(reasons, fmt.Sprintf("%s was removed", reqType))
+		}
+		if change, ok := changed[reqType]; ok {
+			for _, fieldChange := range change.Changes {
+				reasons = append(reasons, fmt.Sprintf("%s %s changed", reqType, fieldChange.Field))
+			}
+			for _, name := range change.RemovedProperties {
+				if _, used := reqMap[name]; used {
+					reasons = append(reasons, fmt.Sprintf("'%s' was removed", name))
+				}
+			}
+			for _, rename := range change.RenamedProperties {
+				if _, used := reqMap[rename.From]; used {
+					reasons = append(reasons, fmt.Sprintf("'%s' was renamed to '%s'", rename.From, rename.To))
+				}
+			}
+			for _, propChange := range change.ChangedProperties {
+				if _, used := reqMap[propChange.Property]; !used {
+					continue
+				}
+				var fields []string
+				for _, fieldChange := range propChange.Changes {
+					fields = append(fields, fieldChange.Field)
+				}
+				reasons = append(reasons, fmt.Sprintf("'%s' changed: %s", propChange.Property, strings.Join(fields, ", ")))
+			}
+		}
+
+		if len(reasons) > 0 {
+			affected = append(affected, AffectedRequest{Index: i, Request: reqType, Reasons: reasons})
+		}
+	}
+	return affected, nil
+}
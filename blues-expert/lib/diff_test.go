@@ -0,0 +1,49 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffAPIEntries(t *testing.T) {
+	maxHours := 720.0
+	from := &APIEntry{
+		Name: "card.voltage",
+		SKUs: []string{"CELL", "WIFI"},
+		Properties: map[string]APIProperty{
+			"hours":   {Type: "integer"},
+			"minutes": {Type: "integer", Description: "Minutes to wait."},
+			"mode":    {Type: "string", Enum: []string{"lipo", "l91"}},
+			"usb":     {Type: "boolean"},
+		},
+	}
+	to := &APIEntry{
+		Name: "card.voltage",
+		SKUs: []string{"WIFI", "CELL"},
+		Properties: map[string]APIProperty{
+			"hours": {Type: "integer", Maximum: &maxHours},
+			"mins":  {Type: "integer", Description: "Minutes to wait."},
+			"mode":  {Type: "string", Enum: []string{"l91", "lipo", "tad"}},
+			"alert": {Type: "boolean"},
+		},
+	}
+
+	got := diffAPIEntries(from, to)
+	want := &APIChange{
+		API:               "card.voltage",
+		AddedProperties:   []string{"alert"},
+		RemovedProperties: []string{"usb"},
+		RenamedProperties: []PropertyRename{{From: "minutes", To: "mins"}},
+		ChangedProperties: []PropertyChange{
+			{Property: "hours", Changes: []FieldChange{{Field: "maximum", To: 720.0}}},
+			{Property: "mode", Changes: []FieldChange{{Field: "enum", From: []string{"l91", "lipo"}, To: []string{"l91", "lipo", "tad"}}}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffAPIEntries() = %#v, want %#v", got, want)
+	}
+
+	if change := diffAPIEntries(from, from); change != nil {
+		t.Errorf("diffAPIEntries() of identical APIs = %#v, want nil", change)
+	}
+}
@@ -0,0 +1,184 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// docsDirPollInterval is how often the docs directory is checked for changes
+const docsDirPollInterval = 2 * time.Second
+
+var (
+	// docsDir is a directory of firmware documents overlaid onto the embedded documentation
+	docsDir string
+	// docLibrary holds the Markdown of every firmware document, keyed by "sdk/document_type"
+	docLibrary      map[string]string
+	docLibraryMutex sync.RWMutex
+)
+
+// SetDocsDir overlays a directory of firmware documents onto the embedded documentation. It uses the
+// same layout as lib/docs ({sdk}/{document_type}.md): documents replace the embedded document of the
+// same name, and new SDKs or document types are added.
+func SetDocsDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read docs directory: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("docs directory %s is not a directory", dir)
+	}
+	docsDir = dir
+	return ReloadDocs()
+}
+
+// ReloadDocs reloads the firmware documentation from the embedded documents and the docs directory,
+// and rebuilds the local search index
+func ReloadDocs() error {
+	library, err := loadDocLibrary()
+	if err != nil {
+		return err
+	}
+
+	docLibraryMutex.Lock()
+	docLibrary = library
+	docLibraryMutex.Unlock()
+
+	resetLocalSearchIndex()
+	return nil
+}
+
+// WatchDocsDir polls the docs directory until the context is cancelled, reloading the documentation
+// and calling onReload whenever a file is added, changed or removed
+func WatchDocsDir(ctx context.Context, onReload func()) {
+	if docsDir == "" {
+		return
+	}
+
+	ticker := time.NewTicker(docsDirPollInterval)
+	defer ticker.Stop()
+
+	fingerprint := docsDirFingerprint()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := docsDirFingerprint()
+		if current == fingerprint {
+			continue
+		}
+		fingerprint = current
+
+		if err := ReloadDocs(); err != nil {
+			log.Warn().Err(err).Str("dir", docsDir).Msg("Failed to reload firmware documentation, keeping the previous version")
+			continue
+		}
+		log.Info().Str("dir", docsDir).Int("documents", len(docFiles())).Msg("Firmware documentation reloaded")
+		if onReload != nil {
+			onReload()
+		}
+	}
+}
+
+// docFiles returns the Markdown of every firmware document, keyed by "sdk/document_type"
+func docFiles() map[string]string {
+	docLibraryMutex.RLock()
+	library := docLibrary
+	docLibraryMutex.RUnlock()
+	if library != nil {
+		return library
+	}
+
+	docLibraryMutex.Lock()
+	defer docLibraryMutex.Unlock()
+	if docLibrary == nil {
+		library, err := loadDocLibrary()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to load firmware documentation")
+			return map[string]string{}
+		}
+		docLibrary = library
+	}
+	return docLibrary
+}
+
+// docKeys returns the "sdk/document_type" keys of a document library in sorted order
+func docKeys(library map[string]string) []string {
+	keys := make([]string, 0, len(library))
+	for key := range library {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// loadDocLibrary reads the embedded documents, then the documents in the docs directory over them
+func loadDocLibrary() (map[string]string, error) {
+	library := make(map[string]string)
+	if err := addDocFiles(library, docs, "docs"); err != nil {
+		return nil, fmt.Errorf("failed to read embedded docs: %v", err)
+	}
+	if docsDir != "" {
+		if err := addDocFiles(library, os.DirFS(docsDir), "."); err != nil {
+			return nil, fmt.Errorf("failed to read docs directory %s: %v", docsDir, err)
+		}
+	}
+	return library, nil
+}
+
+// addDocFiles adds the {sdk}/{document_type}.md files under root to a document library. Other
+// files are ignored.
+func addDocFiles(library map[string]string, fsys fs.FS, root string) error {
+	return fs.WalkDir(fsys, root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(filePath) != ".md" {
+			return nil
+		}
+
+		relPath := filePath
+		if root != "." {
+			relPath = strings.TrimPrefix(filePath, root+"/")
+		}
+		sdk, fileName, ok := strings.Cut(relPath, "/")
+		if !ok || strings.Contains(fileName, "/") {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+		library[strings.ToLower(sdk)+"/"+strings.TrimSuffix(fileName, ".md")] = string(data)
+		return nil
+	})
+}
+
+// docsDirFingerprint summarizes the paths, sizes and modification times of the files in the docs
+// directory, so that changes can be detected by comparing fingerprints
+func docsDirFingerprint() string {
+	var b strings.Builder
+	filepath.WalkDir(docsDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			fmt.Fprintf(&b, "%s %d %d\n", filePath, info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSetDocsDir(t *testing.T) {
+	dir := t.TempDir()
+	writeDoc := func(name string, content string) {
+		t.Helper()
+		filePath := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeDoc("arduino/templates.md", "---\ntitle: Our Templates\n---\n\n# Our Templates\n\nUse our Notefile conventions.\n")
+	writeDoc("arduino/board_variants.md", "# Board Variants\n\nThe rev B board uses the Swan.\n")
+	writeDoc("README.md", "Not a document.\n")
+
+	t.Cleanup(func() {
+		docsDir = ""
+		ReloadDocs()
+	})
+	if err := SetDocsDir(dir); err != nil {
+		t.Fatalf("SetDocsDir() error = %v", err)
+	}
+
+	content, err := readDoc("arduino", "templates")
+	if err != nil || !strings.Contains(string(content), "Notefile conventions") {
+		t.Errorf("readDoc() did not return the overriding document: %q, %v", content, err)
+	}
+	if !slices.Contains(ListDocTypes("arduino"), "board_variants") {
+		t.Errorf("ListDocTypes() = %v, missing the added document", ListDocTypes("arduino"))
+	}
+	if _, err := readDoc("c", "index"); err != nil {
+		t.Errorf("readDoc() of an embedded document error = %v", err)
+	}
+	if results := SearchLocalDocs("rev B board Swan"); len(results) == 0 || !strings.HasPrefix(results[0].URL, "blues://docs/arduino/board_variants") {
+		t.Errorf("SearchLocalDocs() did not find the added document: %#v", results)
+	}
+
+	if err := SetDocsDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("SetDocsDir() of a missing directory succeeded")
+	}
+}
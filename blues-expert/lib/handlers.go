@@ -4,7 +4,9 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -13,23 +15,109 @@ import (
 
 // FirmwareEntrypointArgs defines the arguments for the firmware entrypoint tool
 type FirmwareEntrypointArgs struct {
-	Sdk string `json:"sdk" jsonschema:"The sdk to use for the firmware project. Must be one of: Arduino, C, Zephyr, Python"`
+	Sdk string `json:"sdk" jsonschema:"The SDK to use for the firmware project (e.g., 'arduino', 'c', 'zephyr', 'python')"`
 }
 
 // FirmwareBestPracticesArgs defines the arguments for the firmware best practices tool
 type FirmwareBestPracticesArgs struct {
 	Sdk          string `json:"sdk" jsonschema:"The sdk to use for the firmware project. Must be one of: arduino, c, zephyr, python"`
 	DocumentType string `json:"document_type" jsonschema:"The type of documentation to retrieve (e.g., 'best_practices', 'templates', 'debugging', 'connectivity', 'sensors', 'power_management')"`
+	Section      string `json:"section,omitempty" jsonschema:"Optional heading or anchor of the section to return (e.g., 'creating-a-template', 'Verifying a Template'). The section is returned with its subsections"`
+	Keyword      string `json:"keyword,omitempty" jsonschema:"Optional keyword. Only the sections mentioning it are returned"`
+	TOC          bool   `json:"toc,omitempty" jsonschema:"If true, return the document's table of contents instead of its content"`
+}
+
+// FirmwareDocsListArgs defines the arguments for the firmware documentation catalog tool
+type FirmwareDocsListArgs struct {
+	Sdk string `json:"sdk,omitempty" jsonschema:"Optional SDK to list documentation for. Must be one of: arduino, c, zephyr, python. If omitted, the documentation of every SDK is listed"`
 }
 
 // RequestValidateArgs defines the arguments for the notecard request validation tool
 type RequestValidateArgs struct {
-	Request string `json:"request" jsonschema:"The JSON string of the request to validate (e.g., '{\"req\":\"card.version\"}', '{\"req\":\"card.temp\",\"minutes\":60}')"`
+	Request       string `json:"request" jsonschema:"The JSON string of the request to validate (e.g., '{\"req\":\"card.version\"}', '{\"req\":\"card.temp\",\"minutes\":60}')"`
+	SKU           string `json:"sku,omitempty" jsonschema:"Optional target Notecard SKU (e.g., 'NOTE-WBNAW', 'NOTE-MBGLB') or family ('CELL', 'CELL+WIFI', 'LORA', 'WIFI'). When provided, requests, properties and values not supported on that hardware are reported"`
+	Firmware      string `json:"firmware,omitempty" jsonschema:"Optional target Notecard firmware version (e.g., '8.1.4'). When provided, requests, properties and values that the schema records as requiring newer firmware are reported"`
+	SchemaVersion string `json:"schema_version,omitempty" jsonschema:"Optional Notecard API schema version to validate against (e.g., '0.2.1'). Defaults to the latest release"`
+}
+
+// ResponseValidateArgs defines the arguments for the notecard response validation tool
+type ResponseValidateArgs struct {
+	Request       string `json:"request" jsonschema:"The request the response answers, as an API name (e.g., 'card.version') or the JSON request (e.g., '{\"req\":\"note.add\",\"file\":\"data.qo\"}')"`
+	Response      string `json:"response" jsonschema:"The JSON response returned by the Notecard (e.g., '{\"total\":1}')"`
+	SchemaVersion string `json:"schema_version,omitempty" jsonschema:"Optional Notecard API schema version to validate against (e.g., '0.2.1'). Defaults to the latest release"`
+}
+
+// BatchValidateArgs defines the arguments for the notecard batch request validation tool
+type BatchValidateArgs struct {
+	Requests string `json:"requests" jsonschema:"A JSON array of requests (e.g., '[{\"req\":\"card.version\"},{\"req\":\"hub.set\",\"mode\":\"periodic\"}]') or a sequence of JSON requests, such as newline-delimited JSON with one request per line or pretty-printed requests one after another"`
+}
+
+// SourceValidateArgs defines the arguments for the notecard source validation tool
+type SourceValidateArgs struct {
+	Source   string `json:"source" jsonschema:"Firmware source code that builds Notecard requests (e.g., note-c NoteNewRequest/JAdd*ToObject chains, note-arduino notecard.newRequest calls, Python card.Transaction({...}) calls or raw JSON request strings)"`
+	Language string `json:"language,omitempty" jsonschema:"The language of the source code. Valid values are: c, cpp, arduino, python, json. If omitted, all supported request styles are detected"`
 }
 
 // GetAPIsArgs defines the arguments for the notecard API documentation tool
 type GetAPIsArgs struct {
-	API string `json:"api,omitempty" jsonschema:"The specific Notecard API to get documentation for (e.g., 'card.attn', 'card.version', 'hub.status', 'note.add')"`
+	API           string `json:"api,omitempty" jsonschema:"The specific Notecard API to get documentation for (e.g., 'card.attn', 'card.version', 'hub.status', 'note.add')"`
+	SchemaVersion string `json:"schema_version,omitempty" jsonschema:"Optional Notecard API schema version to document (e.g., '0.2.1'). Defaults to the latest release"`
+	Format        string `json:"format,omitempty" jsonschema:"Optional output format. Valid values are: json (default), markdown (a reference with property tables, enum values, SKUs and samples), compact (one line per property)"`
+}
+
+// APISearchArgs defines the arguments for the notecard API search tool
+type APISearchArgs struct {
+	Query         string `json:"query,omitempty" jsonschema:"Optional keywords to search for in API names, descriptions and property descriptions (e.g., 'gps tracking', 'voltage battery', 'inbound sync')"`
+	Namespace     string `json:"namespace,omitempty" jsonschema:"Optional namespace to limit the search to: card, dfu, env, file, hub, note, ntn, var or web. Nested namespaces such as 'card.location' are also accepted"`
+	SKU           string `json:"sku,omitempty" jsonschema:"Optional Notecard SKU (e.g., 'NOTE-WBNAW') or family ('CELL', 'CELL+WIFI', 'LORA', 'WIFI'). Only APIs supported on that hardware are returned"`
+	Limit         int    `json:"limit,omitempty" jsonschema:"Optional maximum number of APIs to return. Defaults to 10, at most 50"`
+	SchemaVersion string `json:"schema_version,omitempty" jsonschema:"Optional Notecard API schema version to search (e.g., '0.2.1'). Defaults to the latest release"`
+}
+
+// APIDiffArgs defines the arguments for the notecard API schema diff tool
+type APIDiffArgs struct {
+	From     string `json:"from" jsonschema:"The schema version to compare from (e.g., '0.2.1')"`
+	To       string `json:"to,omitempty" jsonschema:"The schema version to compare to. Defaults to the latest release"`
+	API      string `json:"api,omitempty" jsonschema:"Optional API name or namespace to limit the comparison to (e.g., 'card.wireless', 'hub')"`
+	Requests string `json:"requests,omitempty" jsonschema:"Optional JSON array or newline-delimited JSON of the requests your firmware uses. Requests affected by the differences are reported"`
+}
+
+// TemplateCheckArgs defines the arguments for the Note template check tool
+type TemplateCheckArgs struct {
+	Template string `json:"template" jsonschema:"The template to check: a Note template body (e.g., '{\"temp\":14.1,\"alert\":true,\"status\":\"10\"}') or a whole note.template request with its body, format and length"`
+	Note     string `json:"note,omitempty" jsonschema:"Optional sample Note to check against the template: a Note body or a whole note.add request with its body and payload"`
+}
+
+// DataUsageArgs defines the arguments for the cellular data usage estimate tool
+type DataUsageArgs struct {
+	HubSet               string `json:"hub_set" jsonschema:"The hub.set request that configures syncing (e.g., '{\"req\":\"hub.set\",\"mode\":\"periodic\",\"outbound\":60,\"inbound\":720}')"`
+	Notefiles            string `json:"notefiles" jsonschema:"JSON array of the Notefiles synced to Notehub. Each has a 'file' name, a 'template' (note.template request or template body) and/or a sample 'note' (note.add request or Note body), and 'notes_per_hour'"`
+	SessionOverheadBytes int    `json:"session_overhead_bytes,omitempty" jsonschema:"Optional bytes used to open each Notehub session. Defaults to 2048"`
+}
+
+// PowerEstimateArgs defines the arguments for the power estimate tool
+type PowerEstimateArgs struct {
+	Requests               string  `json:"requests,omitempty" jsonschema:"JSON array or newline-delimited JSON of the Notecard configuration requests, e.g. hub.set, card.location.mode and card.motion.mode"`
+	SKU                    string  `json:"sku,omitempty" jsonschema:"Optional Notecard SKU (e.g., 'NOTE-WBNA-500', 'NOTE-NBGL') or family ('CELL', 'CELL+WIFI', 'LORA', 'WIFI'), to look up its published current figures. Defaults to CELL"`
+	NotecardIdleUA         float64 `json:"notecard_idle_ua,omitempty" jsonschema:"Optional Notecard idle current in µA, measured on the device"`
+	NotecardSessionMA      float64 `json:"notecard_session_ma,omitempty" jsonschema:"Optional average Notecard current during a Notehub sync session in mA, measured on the device"`
+	NotecardSessionSeconds float64 `json:"notecard_session_seconds,omitempty" jsonschema:"Optional length of a Notehub sync session in seconds, measured on the device"`
+	NotecardContinuousMA   float64 `json:"notecard_continuous_ma,omitempty" jsonschema:"Optional average Notecard current in continuous mode in mA, measured on the device"`
+	GPSActiveMA            float64 `json:"gps_active_ma,omitempty" jsonschema:"Optional Notecard current while the GPS is on in mA, measured on the device"`
+	GPSFixSeconds          float64 `json:"gps_fix_seconds,omitempty" jsonschema:"Optional seconds the GPS takes to get a fix, measured on the device"`
+	MotionUA               float64 `json:"motion_ua,omitempty" jsonschema:"Optional accelerometer current in µA, measured on the device"`
+	HostSleepUA            float64 `json:"host_sleep_ua,omitempty" jsonschema:"Optional host MCU sleep current in µA"`
+	HostActiveMA           float64 `json:"host_active_ma,omitempty" jsonschema:"Optional host MCU current while awake in mA"`
+	HostActiveSeconds      float64 `json:"host_active_seconds,omitempty" jsonschema:"Optional seconds the host MCU stays awake on each wake"`
+	HostWakeMinutes        float64 `json:"host_wake_minutes,omitempty" jsonschema:"Optional minutes between host MCU wakes"`
+	BatteryMAh             float64 `json:"battery_mah,omitempty" jsonschema:"Optional battery capacity in mAh, to estimate battery life"`
+}
+
+// SimulateArgs defines the arguments for the Notecard simulation tool
+type SimulateArgs struct {
+	Requests string `json:"requests" jsonschema:"The Notecard request (e.g., '{\"req\":\"card.version\"}') or sequence of requests as a JSON array or newline-delimited JSON"`
+	Stateful bool   `json:"stateful,omitempty" jsonschema:"Optional. Apply note.add, note.get, file.changes, env.set, env.get, hub.set, hub.get and hub.sync requests to a simulated Notecard kept for this session, so later requests see their effects"`
+	Reset    bool   `json:"reset,omitempty" jsonschema:"Optional. Start the session's simulated Notecard afresh before sending the requests, whether or not they are sent with stateful"`
 }
 
 // SearchArgs defines the arguments for the notecard search tool
@@ -41,29 +129,26 @@ type SearchArgs struct {
 var docs embed.FS
 
 // Firmware Tools
-func HandleFirmwareEntrypointTool(ctx context.Context, request *mcp.CallToolRequest, args FirmwareEntrypointArgs) (*mcp.CallToolResult, any, error) {
+func HandleFirmwareEntrypointTool(ctx context.Context, request *mcp.CallToolRequest, args FirmwareEntrypointArgs) (*mcp.CallToolResult, *FirmwareDocOutput, error) {
 	TrackSession(request, "firmware_entrypoint")
 
 	if args.Sdk == "" {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: "Error: SDK parameter is required and cannot be empty. Valid values are: Arduino, C, Zephyr, Python"},
+				&mcp.TextContent{Text: "Error: SDK parameter is required and cannot be empty. Valid values are: " + strings.Join(ListDocSDKs(), ", ")},
 			},
 			IsError: true,
 		}, nil, nil
 	}
 
-	// Get the SDK & Index file - convert to lowercase for directory name
-	sdk := strings.ToLower(args.Sdk)
-	indexFile := fmt.Sprintf("docs/%s/index.md", sdk)
-
-	// Get the docs
-	docContent, err := docs.ReadFile(indexFile)
+	// Get the SDK's index document
+	docContent, err := readDoc(args.Sdk, "index")
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Error reading docs: %v", err)},
+				&mcp.TextContent{Text: fmt.Sprintf("Error: %v", docNotFoundError(args.Sdk, "index"))},
 			},
+			IsError: true,
 		}, nil, nil
 	}
 
@@ -71,10 +156,10 @@ func HandleFirmwareEntrypointTool(ctx context.Context, request *mcp.CallToolRequ
 		Content: []mcp.Content{
 			&mcp.TextContent{Text: string(docContent)},
 		},
-	}, nil, nil
+	}, firmwareDocOutput(strings.ToLower(args.Sdk), "index", string(docContent)), nil
 }
 
-func HandleFirmwareBestPracticesTool(ctx context.Context, request *mcp.CallToolRequest, args FirmwareBestPracticesArgs) (*mcp.CallToolResult, any, error) {
+func HandleFirmwareBestPracticesTool(ctx context.Context, request *mcp.CallToolRequest, args FirmwareBestPracticesArgs) (*mcp.CallToolResult, *FirmwareDocOutput, error) {
 	TrackSession(request, "firmware_best_practices")
 
 	if args.Sdk == "" {
@@ -95,16 +180,78 @@ func HandleFirmwareBestPracticesTool(ctx context.Context, request *mcp.CallToolR
 		}, nil, nil
 	}
 
-	// Convert SDK to lowercase for directory name
+	// Get the docs, listing the documents available for the SDK if the document does not exist
+	docContent, err := readDoc(args.Sdk, args.DocumentType)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Error: %v", docNotFoundError(args.Sdk, args.DocumentType))},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
 	sdk := strings.ToLower(args.Sdk)
-	docFile := fmt.Sprintf("docs/%s/%s.md", sdk, args.DocumentType)
+	if args.TOC {
+		headings := docOutline(string(docContent))
+		toc := docTableOfContents(sdk, args.DocumentType, headings)
+		output := firmwareDocOutput(sdk, args.DocumentType, toc)
+		output.Sections = headings
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: toc},
+			},
+		}, output, nil
+	}
 
-	// Get the docs
-	docContent, err := docs.ReadFile(docFile)
+	// Return only the requested sections, if any
+	if args.Section != "" || args.Keyword != "" {
+		sections, err := selectDocSections(string(docContent), args.Section, args.Keyword)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Error: %v", err)},
+				},
+				IsError: true,
+			}, nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sections},
+			},
+		}, firmwareDocOutput(sdk, args.DocumentType, sections), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(docContent)},
+		},
+	}, firmwareDocOutput(sdk, args.DocumentType, string(docContent)), nil
+}
+
+func HandleFirmwareDocsListTool(ctx context.Context, request *mcp.CallToolRequest, args FirmwareDocsListArgs) (*mcp.CallToolResult, *FirmwareDocsListOutput, error) {
+	TrackSession(request, "firmware_docs_list")
+
+	var entries []DocEntry
+	for _, doc := range ListDocs() {
+		if args.Sdk == "" || doc.SDK == strings.ToLower(args.Sdk) {
+			entries = append(entries, doc)
+		}
+	}
+	if len(entries) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Error: %v", docNotFoundError(args.Sdk, ""))},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	response, err := json.MarshalIndent(entries, "", "  ")
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Error reading documentation: %v. Make sure the SDK ('%s') and document_type ('%s') are valid.", err, sdk, args.DocumentType)},
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to format documentation catalog: %v", err)},
 			},
 			IsError: true,
 		}, nil, nil
@@ -112,13 +259,15 @@ func HandleFirmwareBestPracticesTool(ctx context.Context, request *mcp.CallToolR
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
-			&mcp.TextContent{Text: string(docContent)},
+			&mcp.TextContent{
+				Text: fmt.Sprintf("Found %d firmware document(s). Use the firmware_best_practices tool with the sdk and document_type to read one.\n\n%s", len(entries), string(response)),
+			},
 		},
-	}, nil, nil
+	}, &FirmwareDocsListOutput{Documents: entries}, nil
 }
 
 // Notecard API Tools
-func HandleAPIValidateTool(ctx context.Context, request *mcp.CallToolRequest, args RequestValidateArgs) (*mcp.CallToolResult, any, error) {
+func HandleAPIValidateTool(ctx context.Context, request *mcp.CallToolRequest, args RequestValidateArgs) (*mcp.CallToolResult, *RequestValidationResult, error) {
 	TrackSession(request, "api_validate")
 
 	var reqMap map[string]interface{}
@@ -128,38 +277,290 @@ func HandleAPIValidateTool(ctx context.Context, request *mcp.CallToolRequest, ar
 				&mcp.TextContent{Text: fmt.Sprintf("Invalid JSON request: %v", err)},
 			},
 			IsError: true,
-		}, nil, nil
+		}, &RequestValidationResult{Error: fmt.Sprintf("invalid JSON request: %v", err)}, nil
 	}
 
-	if err := ValidateNotecardRequest(reqMap, ""); err != nil {
+	schemaURL, err := ResolveSchemaVersion(args.SchemaVersion)
+	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{Text: fmt.Sprintf("Validation failed: %v", err)},
 			},
 			IsError: true,
+		}, &RequestValidationResult{Error: err.Error()}, nil
+	}
+
+	// Get schema version and source for metadata
+	schemaVersion := GetSchemaVersion(schemaURL)
+	schemaSource := GetSchemaSource(schemaURL)
+
+	target := ValidationTarget{SKU: args.SKU, Firmware: args.Firmware}
+	result := &RequestValidationResult{SchemaVersion: schemaVersion, SchemaSource: schemaSource}
+	result.Request, _ = reqMap["req"].(string)
+	if result.Request == "" {
+		result.Request, _ = reqMap["cmd"].(string)
+	}
+	if !target.IsZero() {
+		result.Target = &target
+	}
+
+	// Only report firmware support that the schema records versions for
+	checkedTarget := target
+	err = ValidateNotecardRequestForTarget(reqMap, schemaURL, target)
+	if warning := firmwareCheckWarning(reqMap, schemaURL, target); warning != "" {
+		result.Warnings = append(result.Warnings, warning)
+		checkedTarget.Firmware = ""
+	}
+	var warnings string
+	for _, warning := range result.Warnings {
+		warnings += "\n\nWarning: " + warning
+	}
+
+	if err != nil {
+		// Report every schema violation so they can all be fixed in one round-trip
+		var validationErr *RequestValidationError
+		if errors.As(err, &validationErr) {
+			result.Request = validationErr.ReqType
+			result.Findings = validationErr.Findings
+			findings, marshalErr := json.MarshalIndent(validationErr, "", "  ")
+			if marshalErr == nil {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						&mcp.TextContent{Text: fmt.Sprintf("Validation failed: %d problem(s) found in the '%s' request:\n\n%s%s", len(validationErr.Findings), validationErr.ReqType, string(findings), warnings)},
+					},
+					IsError: true,
+				}, result, nil
+			}
+		} else {
+			result.Error = err.Error()
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Validation failed: %v%s", err, warnings)},
+			},
+			IsError: true,
+		}, result, nil
+	}
+
+	text := "Request validation successful: The JSON request is valid according to the Notecard API schema."
+	if !checkedTarget.IsZero() {
+		text = fmt.Sprintf("Request validation successful: The JSON request is valid according to the Notecard API schema and supported by the target Notecard (%s).", describeValidationTarget(checkedTarget))
+	}
+	text += warnings
+	result.Valid = true
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: text,
+				Meta: mcp.Meta{
+					"schema_version": schemaVersion,
+					"schema_source":  schemaSource,
+				},
+			},
+		},
+	}, result, nil
+}
+
+func HandleAPIValidateResponseTool(ctx context.Context, request *mcp.CallToolRequest, args ResponseValidateArgs) (*mcp.CallToolResult, *ResponseValidationResult, error) {
+	TrackSession(request, "api_validate_response")
+
+	schemaURL, err := ResolveSchemaVersion(args.SchemaVersion)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Response validation failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	result, err := CheckNotecardResponse(args.Request, args.Response, schemaURL)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Response validation failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	response, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to format response validation: %v", err)},
+			},
+			IsError: true,
 		}, nil, nil
 	}
 
-	// Get schema version for metadata
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("%s\n\n%s", formatResponseValidation(result), string(response)),
+				Meta: mcp.Meta{
+					"schema_version": result.SchemaVersion,
+					"schema_source":  result.SchemaSource,
+				},
+			},
+		},
+		IsError: !result.Valid,
+	}, result, nil
+}
+
+func HandleAPIValidateBatchTool(ctx context.Context, request *mcp.CallToolRequest, args BatchValidateArgs) (*mcp.CallToolResult, *BatchValidationResult, error) {
+	TrackSession(request, "api_validate_batch")
+
+	result, err := ValidateNotecardRequestBatch(args.Requests, "")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Batch validation failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	response, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to format batch validation results: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	// Get schema version and source for metadata
+	schemaVersion := GetSchemaVersion("")
+	schemaSource := GetSchemaSource("")
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("Batch validation complete: %d of %d request(s) valid, %d invalid.\n\n%s", result.Valid, result.Total, result.Invalid, string(response)),
+				Meta: mcp.Meta{
+					"schema_version": schemaVersion,
+					"schema_source":  schemaSource,
+				},
+			},
+		},
+		IsError: result.Invalid > 0,
+	}, result, nil
+}
+
+func HandleSourceValidateTool(ctx context.Context, request *mcp.CallToolRequest, args SourceValidateArgs) (*mcp.CallToolResult, *SourceValidationResult, error) {
+	TrackSession(request, "source_validate")
+
+	result, err := ValidateSourceRequests(args.Source, args.Language, "")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Source validation failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	response, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to format source validation results: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	// Get schema version and source for metadata
 	schemaVersion := GetSchemaVersion("")
+	schemaSource := GetSchemaSource("")
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			&mcp.TextContent{
-				Text: "Request validation successful: The JSON request is valid according to the Notecard API schema.",
+				Text: fmt.Sprintf("Source validation complete: %d of %d request(s) found in the source are valid, %d invalid.\n\n%s", result.Valid, result.Total, result.Invalid, string(response)),
 				Meta: mcp.Meta{
 					"schema_version": schemaVersion,
+					"schema_source":  schemaSource,
 				},
 			},
 		},
-	}, nil, nil
+		IsError: result.Invalid > 0,
+	}, result, nil
+}
+
+func HandleAPIDiffTool(ctx context.Context, request *mcp.CallToolRequest, args APIDiffArgs) (*mcp.CallToolResult, *SchemaDiff, error) {
+	TrackSession(request, "api_diff")
+
+	if args.From == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Error: from parameter is required and cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	diff, err := DiffSchemaVersions(args.From, args.To, args.API, args.Requests)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to compare schema versions: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	response, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to format schema diff: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("Schema diff from %s to %s: %d API(s) added, %d removed, %d changed.\n\n%s", diff.From, diff.To, len(diff.AddedAPIs), len(diff.RemovedAPIs), len(diff.ChangedAPIs), string(response)),
+			},
+		},
+	}, diff, nil
 }
 
-func HandleAPIDocsTool(ctx context.Context, request *mcp.CallToolRequest, args GetAPIsArgs) (*mcp.CallToolResult, any, error) {
+func HandleAPIDocsTool(ctx context.Context, request *mcp.CallToolRequest, args GetAPIsArgs) (*mcp.CallToolResult, *APIDocsOutput, error) {
 	TrackSession(request, "api_docs")
 
+	format := strings.ToLower(args.Format)
+	if format == "" {
+		format = APIDocsFormatJSON
+	}
+	if !slices.Contains(APIDocsFormats(), format) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Error: invalid format '%s'. Valid formats are: %s", args.Format, strings.Join(APIDocsFormats(), ", "))},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	schemaURL, err := ResolveSchemaVersion(args.SchemaVersion)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to get API documentation: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
 	// Get API documentation
-	apiCategory, err := GetNotecardAPIs(ctx, request, args.API)
+	apiCategory, err := GetNotecardAPIs(ctx, request, args.API, schemaURL)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -169,16 +570,31 @@ func HandleAPIDocsTool(ctx context.Context, request *mcp.CallToolRequest, args G
 		}, nil, nil
 	}
 
-	// Get schema version for metadata
-	schemaVersion := GetSchemaVersion("")
+	// Get schema version and source for metadata
+	schemaVersion := GetSchemaVersion(schemaURL)
+	schemaSource := GetSchemaSource(schemaURL)
 
 	var response []byte
+	output := &APIDocsOutput{SchemaVersion: schemaVersion, SchemaSource: schemaSource}
 	// If specific API requested, return just the API object
 	if args.API != "" && len(apiCategory.APIs) > 0 {
-		response, err = json.MarshalIndent(apiCategory.APIs[0], "", "  ")
+		output.API = &apiCategory.APIs[0]
+		switch format {
+		case APIDocsFormatMarkdown:
+			response = []byte(renderAPIMarkdown(*output.API))
+		case APIDocsFormatCompact:
+			response = []byte(renderAPICompact(*output.API))
+		default:
+			response, err = json.MarshalIndent(output.API, "", "  ")
+		}
 	} else {
 		// Otherwise return the full category structure for listing
-		response, err = json.MarshalIndent(apiCategory, "", "  ")
+		output.Category = apiCategory
+		if format == APIDocsFormatJSON {
+			response, err = json.MarshalIndent(apiCategory, "", "  ")
+		} else {
+			response = []byte(renderAPIListMarkdown(apiCategory, format == APIDocsFormatCompact))
+		}
 	}
 
 	if err != nil {
@@ -196,18 +612,249 @@ func HandleAPIDocsTool(ctx context.Context, request *mcp.CallToolRequest, args G
 				Text: string(response),
 				Meta: mcp.Meta{
 					"schema_version": schemaVersion,
+					"schema_source":  schemaSource,
+				},
+			},
+		},
+	}, output, nil
+}
+
+func HandleAPISearchTool(ctx context.Context, request *mcp.CallToolRequest, args APISearchArgs) (*mcp.CallToolResult, *APISearchOutput, error) {
+	TrackSession(request, "api_search")
+
+	schemaURL, err := ResolveSchemaVersion(args.SchemaVersion)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("API search failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	output, err := SearchNotecardAPIs(args.Query, args.Namespace, args.SKU, args.Limit, schemaURL)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("API search failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: formatAPISearchResults(output),
+				Meta: mcp.Meta{
+					"schema_version": GetSchemaVersion(schemaURL),
+					"schema_source":  GetSchemaSource(schemaURL),
+				},
+			},
+		},
+	}, output, nil
+}
+
+func HandleTemplateCheckTool(ctx context.Context, request *mcp.CallToolRequest, args TemplateCheckArgs) (*mcp.CallToolResult, *TemplateCheckResult, error) {
+	TrackSession(request, "template_check")
+
+	if args.Template == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Error: template parameter is required and cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	result, err := CheckNoteTemplate(args.Template, args.Note)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Template check failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	response, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to format template check: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("%s\n\n%s", formatTemplateCheck(result, args.Note != ""), string(response)),
+			},
+		},
+		IsError: !result.Valid,
+	}, result, nil
+}
+
+func HandleDataUsageEstimateTool(ctx context.Context, request *mcp.CallToolRequest, args DataUsageArgs) (*mcp.CallToolResult, *DataUsageEstimate, error) {
+	TrackSession(request, "data_usage_estimate")
+
+	if args.HubSet == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Error: hub_set parameter is required and cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	estimate, err := EstimateDataUsage(args.HubSet, args.Notefiles, args.SessionOverheadBytes, "")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Data usage estimate failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	response, err := json.MarshalIndent(estimate, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to format data usage estimate: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("%s\n\n%s", formatDataUsageEstimate(estimate), string(response)),
+				Meta: mcp.Meta{
+					"schema_version": GetSchemaVersion(""),
+					"schema_source":  GetSchemaSource(""),
+				},
+			},
+		},
+		IsError: !estimate.Valid,
+	}, estimate, nil
+}
+
+func HandlePowerEstimateTool(ctx context.Context, request *mcp.CallToolRequest, args PowerEstimateArgs) (*mcp.CallToolResult, *PowerEstimate, error) {
+	TrackSession(request, "power_estimate")
+
+	notecard := NotecardProfile{
+		IdleMicroamps:       args.NotecardIdleUA,
+		SessionMilliamps:    args.NotecardSessionMA,
+		SessionSeconds:      args.NotecardSessionSeconds,
+		ContinuousMilliamps: args.NotecardContinuousMA,
+		GPSMilliamps:        args.GPSActiveMA,
+		GPSFixSeconds:       args.GPSFixSeconds,
+		MotionMicroamps:     args.MotionUA,
+	}
+	host := HostProfile{
+		SleepMicroamps:  args.HostSleepUA,
+		ActiveMilliamps: args.HostActiveMA,
+		ActiveSeconds:   args.HostActiveSeconds,
+		WakeMinutes:     args.HostWakeMinutes,
+	}
+	estimate, err := EstimatePower(args.Requests, args.SKU, notecard, host, args.BatteryMAh, "")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Power estimate failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	response, err := json.MarshalIndent(estimate, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to format power estimate: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("%s\n\n%s", formatPowerEstimate(estimate), string(response)),
+			},
+		},
+		IsError: !estimate.Valid,
+	}, estimate, nil
+}
+
+func HandleNotecardSimulateTool(ctx context.Context, request *mcp.CallToolRequest, args SimulateArgs) (*mcp.CallToolResult, *SimulationOutput, error) {
+	TrackSession(request, "notecard_simulate")
+
+	if args.Requests == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Error: requests parameter is required and cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	// reset clears the session's simulated Notecard even when the requests are sent statelessly
+	var notecard *SimulatedNotecard
+	if args.Stateful || args.Reset {
+		sessionNotecard := GetSessionManager().GetSimulatedNotecard(GetSessionIDFromRequest(request), args.Reset)
+		if args.Stateful {
+			notecard = sessionNotecard
+		}
+	}
+	output, err := SimulateNotecardRequests(args.Requests, notecard, "")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Simulation failed: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	response, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to format simulation: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	valid := true
+	for _, exchange := range output.Exchanges {
+		valid = valid && exchange.Valid
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("%s\n%s", formatSimulation(output, args.Requests), string(response)),
+				Meta: mcp.Meta{
+					"schema_version": GetSchemaVersion(""),
+					"schema_source":  GetSchemaSource(""),
 				},
 			},
 		},
-	}, nil, nil
+		IsError: !valid,
+	}, output, nil
 }
 
 // Blues Documentation Tools
-func HandleDocsSearchTool(ctx context.Context, request *mcp.CallToolRequest, args SearchArgs) (*mcp.CallToolResult, any, error) {
+func HandleDocsSearchTool(ctx context.Context, request *mcp.CallToolRequest, args SearchArgs) (*mcp.CallToolResult, *DocsSearchOutput, error) {
 	TrackSession(request, "docs_search")
 
 	// Call the search implementation from query.go
-	result, err := SearchNotecardDocs(ctx, request, args.Query)
+	result, output, err := SearchNotecardDocs(ctx, request, args.Query)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -227,5 +874,5 @@ func HandleDocsSearchTool(ctx context.Context, request *mcp.CallToolRequest, arg
 		}
 	}
 
-	return result, nil, nil
+	return result, output, nil
 }
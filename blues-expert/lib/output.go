@@ -0,0 +1,65 @@
+package lib
+
+// The types below are the structured output of the tools. The MCP server derives each tool's output
+// schema from them, and the same content is rendered as text for clients without structured output.
+
+// FirmwareDocOutput is the structured output of the firmware_entrypoint and firmware_best_practices tools
+type FirmwareDocOutput struct {
+	SDK          string       `json:"sdk" jsonschema:"The SDK the document is for"`
+	DocumentType string       `json:"document_type" jsonschema:"The type of the document (e.g., 'index', 'templates')"`
+	Title        string       `json:"title" jsonschema:"The title of the document"`
+	URI          string       `json:"uri" jsonschema:"The resource URI of the document"`
+	Content      string       `json:"content" jsonschema:"The Markdown of the document, of the selected sections, or of its table of contents"`
+	Sections     []DocHeading `json:"sections,omitempty" jsonschema:"The headings of the document, when its table of contents was requested"`
+}
+
+// DocHeading is an entry in the table of contents of a firmware document
+type DocHeading struct {
+	Level   int    `json:"level" jsonschema:"The heading level, from 1 to 3"`
+	Heading string `json:"heading" jsonschema:"The heading text"`
+	Anchor  string `json:"anchor" jsonschema:"The anchor to pass as 'section' to retrieve the section"`
+	Lines   int    `json:"lines" jsonschema:"The number of lines in the section, including its subsections"`
+}
+
+// FirmwareDocsListOutput is the structured output of the firmware_docs_list tool
+type FirmwareDocsListOutput struct {
+	Documents []DocEntry `json:"documents,omitempty" jsonschema:"The firmware documents available"`
+}
+
+// RequestValidationResult is the structured output of the api_validate tool
+type RequestValidationResult struct {
+	Valid         bool                `json:"valid" jsonschema:"Whether the request is valid"`
+	Request       string              `json:"request,omitempty" jsonschema:"The name of the request, e.g. 'card.version'"`
+	Error         string              `json:"error,omitempty" jsonschema:"Why the request could not be validated, e.g. invalid JSON"`
+	Findings      []ValidationFinding `json:"findings,omitempty" jsonschema:"Every problem found in the request"`
+	Target        *ValidationTarget   `json:"target,omitempty" jsonschema:"The target Notecard the request was checked against"`
+	Warnings      []string            `json:"warnings,omitempty" jsonschema:"Checks that could not be made, e.g. a target firmware version the schema records no minimum version for"`
+	SchemaVersion string              `json:"schema_version,omitempty" jsonschema:"The Notecard API schema version used"`
+	SchemaSource  string              `json:"schema_source,omitempty" jsonschema:"Where the Notecard API schema was loaded from"`
+}
+
+// ResponseValidationResult is the structured output of the api_validate_response tool
+type ResponseValidationResult struct {
+	Valid                bool                `json:"valid" jsonschema:"Whether the response is valid according to the response schema of its request"`
+	Request              string              `json:"request,omitempty" jsonschema:"The name of the request the response answers, e.g. 'card.version'"`
+	NotecardError        string              `json:"notecard_error,omitempty" jsonschema:"The error returned by the Notecard in the 'err' property of the response, if any"`
+	Findings             []ValidationFinding `json:"findings,omitempty" jsonschema:"Every problem found in the response"`
+	Undocumented         []ValidationFinding `json:"undocumented,omitempty" jsonschema:"Properties of the response not documented by the response schema. They do not make the response invalid"`
+	SchemaVersion        string              `json:"schema_version,omitempty" jsonschema:"The Notecard API schema version used"`
+	SchemaSource         string              `json:"schema_source,omitempty" jsonschema:"Where the Notecard API schema was loaded from"`
+	ResponseSchemaSource string              `json:"response_schema_source,omitempty" jsonschema:"Where the response schema was loaded from. 'synthesized' schemas are bundled stand-ins, not part of the Notecard API schema"`
+}
+
+// APIDocsOutput is the structured output of the api_docs tool
+type APIDocsOutput struct {
+	API           *APIEntry    `json:"api,omitempty" jsonschema:"The documentation of the requested API"`
+	Category      *APICategory `json:"category,omitempty" jsonschema:"The list of APIs, when no single API was requested"`
+	SchemaVersion string       `json:"schema_version,omitempty" jsonschema:"The Notecard API schema version used"`
+	SchemaSource  string       `json:"schema_source,omitempty" jsonschema:"Where the Notecard API schema was loaded from"`
+}
+
+// DocsSearchOutput is the structured output of the docs_search tool
+type DocsSearchOutput struct {
+	Query   string         `json:"query" jsonschema:"The search query"`
+	Results []SearchResult `json:"results,omitempty" jsonschema:"The search results, best match first"`
+}
@@ -0,0 +1,52 @@
+package lib
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// TestOutputZeroValues checks that the zero value of every tool output satisfies the output schema
+// derived from it, as the MCP server returns the zero value when a tool fails
+func TestOutputZeroValues(t *testing.T) {
+	for _, output := range []any{
+		FirmwareDocOutput{},
+		FirmwareDocsListOutput{},
+		RequestValidationResult{},
+		ResponseValidationResult{},
+		BatchValidationResult{},
+		SourceValidationResult{},
+		APIDocsOutput{},
+		SchemaDiff{},
+		DocsSearchOutput{},
+		APISearchOutput{},
+		TemplateCheckResult{},
+		DataUsageEstimate{},
+		PowerEstimate{},
+		SimulationOutput{},
+	} {
+		name := reflect.TypeOf(output).Name()
+		schema, err := jsonschema.ForType(reflect.TypeOf(output), &jsonschema.ForOptions{})
+		if err != nil {
+			t.Fatalf("%s: ForType() error = %v", name, err)
+		}
+		resolved, err := schema.Resolve(nil)
+		if err != nil {
+			t.Fatalf("%s: Resolve() error = %v", name, err)
+		}
+
+		data, err := json.Marshal(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var instance any
+		if err := json.Unmarshal(data, &instance); err != nil {
+			t.Fatal(err)
+		}
+		if err := resolved.Validate(instance); err != nil {
+			t.Errorf("%s: zero value does not match its output schema: %v", name, err)
+		}
+	}
+}
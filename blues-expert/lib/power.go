@@ -0,0 +1,332 @@
+package lib
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// secondsPerDay is the period average currents are computed over
+const secondsPerDay = 24 * 60 * 60
+
+//go:embed data/notecard_power.json
+var notecardPowerTable []byte
+
+// Names of the Notecard current figures, as keyed in the power table
+const (
+	figureIdle           = "idle_ua"
+	figureSession        = "session_ma"
+	figureSessionSeconds = "session_seconds"
+	figureContinuous     = "continuous_ma"
+	figureGPS            = "gps_active_ma"
+	figureGPSFixSeconds  = "gps_fix_seconds"
+	figureMotion         = "motion_ua"
+)
+
+// powerTable holds the published Notecard current figures used to estimate power consumption
+type powerTable struct {
+	Description           string                            `json:"description"`
+	Hardware              map[string]map[string]PowerFigure `json:"hardware"`
+	BatteryUsableFraction PowerFigure                       `json:"battery_usable_fraction"`
+}
+
+// PowerFigure is a figure used by a power estimate and where it comes from
+type PowerFigure struct {
+	Name   string  `json:"name,omitempty" jsonschema:"The figure, e.g. 'idle_ua', 'session_ma' or 'battery_usable_fraction'"`
+	Value  float64 `json:"value" jsonschema:"The value of the figure"`
+	Source string  `json:"source" jsonschema:"The datasheet section the figure comes from, 'given' for figures passed to the estimate, or an 'assumption' label"`
+}
+
+// NotecardProfile holds Notecard current figures measured on the device (e.g. with Mojo). They take
+// precedence over the published figures of the power table.
+type NotecardProfile struct {
+	IdleMicroamps       float64 `json:"idle_ua,omitempty"`
+	SessionMilliamps    float64 `json:"session_ma,omitempty"`
+	SessionSeconds      float64 `json:"session_seconds,omitempty"`
+	ContinuousMilliamps float64 `json:"continuous_ma,omitempty"`
+	GPSMilliamps        float64 `json:"gps_active_ma,omitempty"`
+	GPSFixSeconds       float64 `json:"gps_fix_seconds,omitempty"`
+	MotionMicroamps     float64 `json:"motion_ua,omitempty"`
+}
+
+// HostProfile describes how the host MCU sleeps and wakes
+type HostProfile struct {
+	SleepMicroamps  float64 `json:"sleep_ua,omitempty"`
+	ActiveMilliamps float64 `json:"active_ma,omitempty"`
+	ActiveSeconds   float64 `json:"active_seconds,omitempty"`
+	WakeMinutes     float64 `json:"wake_minutes,omitempty"`
+}
+
+// PowerActivity is the share of the average current drawn by an activity
+type PowerActivity struct {
+	Activity         string  `json:"activity" jsonschema:"The activity, e.g. 'notecard_idle', 'sync_sessions', 'gps', 'motion', 'host_sleep' or 'host_active'"`
+	AverageMilliamps float64 `json:"average_ma" jsonschema:"The average current drawn by the activity in mA"`
+	Share            float64 `json:"share_percent" jsonschema:"The percentage of the total average current"`
+	Detail           string  `json:"detail" jsonschema:"How the average current was computed"`
+}
+
+// PowerEstimate is the estimated average current and battery life of a Notecard and its host
+type PowerEstimate struct {
+	Valid            bool              `json:"valid" jsonschema:"Whether every configuration request is valid, so that power was estimated"`
+	Requests         []BatchItemResult `json:"requests,omitempty" jsonschema:"The validation result of each configuration request"`
+	SKU              string            `json:"sku,omitempty" jsonschema:"The Notecard SKU or family the current figures are for"`
+	AverageMilliamps float64           `json:"average_ma" jsonschema:"The estimated average current in mA"`
+	BatteryMAh       float64           `json:"battery_mah,omitempty" jsonschema:"The battery capacity in mAh"`
+	BatteryLifeDays  float64           `json:"battery_life_days,omitempty" jsonschema:"The estimated battery life in days, using the usable fraction of the capacity"`
+	Activities       []PowerActivity   `json:"activities,omitempty" jsonschema:"The average current of each activity, largest first"`
+	Figures          []PowerFigure     `json:"figures,omitempty" jsonschema:"The Notecard figures the estimate used and where each comes from"`
+	Unestimated      []string          `json:"unestimated,omitempty" jsonschema:"The Notecard activities left out because their current figures are not known"`
+	Warnings         []string          `json:"warnings,omitempty" jsonschema:"Assumptions, missing figures and settings that affect the estimate"`
+}
+
+// loadPowerTable decodes the Notecard current figures embedded in the binary
+func loadPowerTable() (*powerTable, error) {
+	var table powerTable
+	if err := json.Unmarshal(notecardPowerTable, &table); err != nil {
+		return nil, fmt.Errorf("failed to load Notecard current figures: %v", err)
+	}
+	return &table, nil
+}
+
+// EstimatePower estimates the average current and battery life of a Notecard configured with the given
+// requests (a JSON array or newline-delimited JSON of hub.set, card.location.mode, card.motion.mode and
+// other requests) and of its host MCU. Every request is validated first; if any is invalid the estimate
+// only holds the validation results. Notecard figures measured on the device take precedence over the
+// published figures of the SKU, and activities without figures are left out with a warning.
+func EstimatePower(requests string, sku string, notecard NotecardProfile, host HostProfile, batteryMAh float64, schemaURL string) (*PowerEstimate, error) {
+	table, err := loadPowerTable()
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &PowerEstimate{SKU: strings.ToUpper(strings.TrimSpace(sku)), BatteryMAh: batteryMAh}
+	if estimate.SKU == "" {
+		estimate.SKU = skuFamilyCell
+		estimate.Warnings = append(estimate.Warnings, "no sku given, so the figures of Notecard Cellular are used")
+	}
+	family, err := ResolveSKUFamily(estimate.SKU)
+	if err != nil {
+		return nil, err
+	}
+	published, ok := table.Hardware[estimate.SKU]
+	if !ok {
+		published = table.Hardware[family]
+	}
+
+	// Validate every request, then merge them by type so that later requests override earlier ones
+	config := map[string]map[string]interface{}{}
+	if strings.TrimSpace(requests) != "" {
+		items, err := splitNotecardRequests(requests)
+		if err != nil {
+			return nil, err
+		}
+		if schemaURL == "" {
+			schemaURL = defaultSchemaURL
+		}
+		for i, item := range items {
+			itemResult := BatchItemResult{Index: i, Line: item.line}
+			reqMap := validateRawRequest(&itemResult, item.data, schemaURL)
+			estimate.Requests = append(estimate.Requests, itemResult)
+			if reqMap == nil {
+				continue
+			}
+			if config[itemResult.Request] == nil {
+				config[itemResult.Request] = map[string]interface{}{}
+			}
+			for key, value := range reqMap {
+				config[itemResult.Request][key] = value
+			}
+		}
+		for _, itemResult := range estimate.Requests {
+			if !itemResult.Valid {
+				return estimate, nil
+			}
+		}
+	}
+	estimate.Valid = true
+
+	// Notecard idle or continuous connection
+	schedule := newHubSchedule(config["hub.set"])
+	if config["hub.set"] == nil {
+		estimate.Warnings = append(estimate.Warnings, "no hub.set request, so the Notecard defaults apply: periodic mode without outbound or inbound intervals")
+	}
+	estimate.Warnings = append(estimate.Warnings, schedule.warnings...)
+	if schedule.mode == "continuous" {
+		if continuous := estimate.figure(figureContinuous, notecard.ContinuousMilliamps, published); continuous > 0 {
+			estimate.addActivity("notecard_idle", continuous, fmt.Sprintf("continuous connection at %g mA", continuous))
+		} else {
+			estimate.missingFigures("the continuous connection", family, "notecard_continuous_ma")
+		}
+	} else if idle := estimate.figure(figureIdle, notecard.IdleMicroamps, published); idle > 0 {
+		estimate.addActivity("notecard_idle", idle/1000, fmt.Sprintf("idle at %g µA", idle))
+	} else {
+		estimate.missingFigures("the idle current", family, "notecard_idle_ua")
+	}
+
+	// Notehub sync sessions
+	if sessionsPerDay := schedule.sessions / 30; sessionsPerDay > 0 {
+		current := estimate.figure(figureSession, notecard.SessionMilliamps, published)
+		seconds := estimate.figure(figureSessionSeconds, notecard.SessionSeconds, published)
+		if current > 0 && seconds > 0 {
+			estimate.addActivity("sync_sessions", sessionsPerDay*seconds*current/secondsPerDay,
+				fmt.Sprintf("%.1f session(s) per day of %gs at %g mA", sessionsPerDay, seconds, current))
+		} else {
+			estimate.missingFigures("sync sessions", family, "notecard_session_ma and notecard_session_seconds")
+		}
+	}
+
+	// GPS, sampled periodically when motion is detected or kept on continuously
+	if location := config["card.location.mode"]; location != nil {
+		switch mode, _ := location["mode"].(string); mode {
+		case "continuous":
+			if current := estimate.figure(figureGPS, notecard.GPSMilliamps, published); current > 0 {
+				estimate.addActivity("gps", current, fmt.Sprintf("GPS on continuously at %g mA", current))
+			} else {
+				estimate.missingFigures("GPS", family, "gps_active_ma")
+			}
+		case "periodic":
+			seconds := float64(intValue(location["seconds"]))
+			if seconds <= 0 {
+				estimate.Warnings = append(estimate.Warnings, "card.location.mode is periodic without seconds, so GPS sampling is not included")
+				break
+			}
+			current := estimate.figure(figureGPS, notecard.GPSMilliamps, published)
+			fixSeconds := estimate.figure(figureGPSFixSeconds, notecard.GPSFixSeconds, published)
+			if current <= 0 || fixSeconds <= 0 {
+				estimate.missingFigures("GPS", family, "gps_active_ma and gps_fix_seconds")
+				break
+			}
+			duty := fixSeconds / seconds
+			if duty > 1 {
+				duty = 1
+			}
+			estimate.addActivity("gps", duty*current, fmt.Sprintf("a %gs GPS fix every %gs at %g mA", fixSeconds, seconds, current))
+			estimate.Warnings = append(estimate.Warnings, "periodic location is only sampled when the Notecard detects motion; the device is assumed to always be moving, an upper bound")
+		}
+	}
+
+	// Accelerometer
+	if motion := config["card.motion.mode"]; motion != nil {
+		if start, _ := motion["start"].(bool); start {
+			if stop, _ := motion["stop"].(bool); !stop {
+				if current := estimate.figure(figureMotion, notecard.MotionMicroamps, published); current > 0 {
+					estimate.addActivity("motion", current/1000, fmt.Sprintf("accelerometer at %g µA", current))
+				} else {
+					estimate.missingFigures("the accelerometer", family, "motion_ua")
+				}
+			}
+		}
+	}
+
+	var unmodeled []string
+	for name := range config {
+		if name != "hub.set" && name != "card.location.mode" && name != "card.motion.mode" {
+			unmodeled = append(unmodeled, name)
+		}
+	}
+	sort.Strings(unmodeled)
+	for _, name := range unmodeled {
+		estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("%s has no current figures, so it is not included", name))
+	}
+
+	// Host MCU, sleeping between wakes
+	if host.WakeMinutes > 0 && host.ActiveSeconds > 0 {
+		duty := host.ActiveSeconds / (host.WakeMinutes * 60)
+		if duty > 1 {
+			duty = 1
+		}
+		estimate.addActivity("host_active", duty*host.ActiveMilliamps, fmt.Sprintf("awake for %gs every %g minute(s) at %g mA", host.ActiveSeconds, host.WakeMinutes, host.ActiveMilliamps))
+		estimate.addActivity("host_sleep", (1-duty)*host.SleepMicroamps/1000, fmt.Sprintf("asleep at %g µA between wakes", host.SleepMicroamps))
+	} else if host.SleepMicroamps > 0 || host.ActiveMilliamps > 0 {
+		estimate.Warnings = append(estimate.Warnings, "the host profile needs wake_minutes and active_seconds, so the host is not included")
+	}
+
+	total := 0.0
+	for _, activity := range estimate.Activities {
+		total += activity.AverageMilliamps
+	}
+	if total <= 0 {
+		return estimate, nil
+	}
+	for i := range estimate.Activities {
+		estimate.Activities[i].Share = roundTo(estimate.Activities[i].AverageMilliamps/total*100, 1)
+		estimate.Activities[i].AverageMilliamps = roundTo(estimate.Activities[i].AverageMilliamps, 4)
+	}
+	sort.SliceStable(estimate.Activities, func(i, j int) bool {
+		return estimate.Activities[i].AverageMilliamps > estimate.Activities[j].AverageMilliamps
+	})
+	estimate.AverageMilliamps = roundTo(total, 4)
+	if batteryMAh > 0 {
+		usable := table.BatteryUsableFraction
+		usable.Name = "battery_usable_fraction"
+		estimate.Figures = append(estimate.Figures, usable)
+		estimate.BatteryLifeDays = roundTo(batteryMAh*usable.Value/total/24, 1)
+		estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("battery life assumes %.0f%% of the capacity is usable, allowing for self-discharge and cutoff voltage; this is an assumption, not a published figure", usable.Value*100))
+	}
+	return estimate, nil
+}
+
+// figure returns a Notecard figure measured on the device, or else the published figure of the
+// hardware, and records where it came from. It returns 0 if neither is known.
+func (e *PowerEstimate) figure(name string, given float64, published map[string]PowerFigure) float64 {
+	figure := PowerFigure{Name: name, Value: given, Source: "given"}
+	if given <= 0 {
+		var ok bool
+		if figure, ok = published[name]; !ok {
+			return 0
+		}
+		figure.Name = name
+	}
+	e.Figures = append(e.Figures, figure)
+	return figure.Value
+}
+
+// missingFigures warns that an activity is left out because its figures are not known
+func (e *PowerEstimate) missingFigures(activity string, family string, arguments string) {
+	e.Unestimated = append(e.Unestimated, activity)
+	e.Warnings = append(e.Warnings, fmt.Sprintf("no sourced figures for %s of %s Notecards are bundled, so it is not included; measure it on your device (e.g. with Mojo) and pass %s", activity, family, arguments))
+}
+
+// addActivity records the average current of an activity
+func (e *PowerEstimate) addActivity(activity string, averageMilliamps float64, detail string) {
+	e.Activities = append(e.Activities, PowerActivity{Activity: activity, AverageMilliamps: averageMilliamps, Detail: detail})
+}
+
+// formatPowerEstimate summarizes a power estimate
+func formatPowerEstimate(estimate *PowerEstimate) string {
+	if !estimate.Valid {
+		invalid := 0
+		for _, itemResult := range estimate.Requests {
+			if !itemResult.Valid {
+				invalid++
+			}
+		}
+		return fmt.Sprintf("Power was not estimated: %d of %d configuration request(s) are invalid.", invalid, len(estimate.Requests))
+	}
+
+	if len(estimate.Activities) == 0 {
+		return fmt.Sprintf("Power was not estimated: no current figures are known for %s, and none were given. Measure the Notecard on your device (e.g. with Mojo) and pass its figures.", estimate.SKU)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Estimated average current for %s: %.4f mA", estimate.SKU, estimate.AverageMilliamps)
+	if estimate.BatteryLifeDays > 0 {
+		fmt.Fprintf(&b, ", about %.0f day(s) on a %g mAh battery", estimate.BatteryLifeDays, estimate.BatteryMAh)
+	}
+	b.WriteString(".")
+	for _, activity := range estimate.Activities {
+		fmt.Fprintf(&b, "\n- %s: %.4f mA (%.1f%%), %s", activity.Activity, activity.AverageMilliamps, activity.Share, activity.Detail)
+	}
+	if len(estimate.Unestimated) > 0 {
+		fmt.Fprintf(&b, "\nNot included, as their figures are not known: %s.", strings.Join(estimate.Unestimated, ", "))
+	}
+	if len(estimate.Figures) > 0 {
+		b.WriteString("\nFigures used:")
+		for _, figure := range estimate.Figures {
+			fmt.Fprintf(&b, "\n- %s = %g (%s)", figure.Name, figure.Value, figure.Source)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEstimatePower(t *testing.T) {
+	requests := `[
+		{"req":"hub.set","mode":"periodic","outbound":60,"inbound":720},
+		{"req":"card.location.mode","mode":"periodic","seconds":600},
+		{"req":"card.motion.mode","start":true},
+		{"req":"card.voltage","mode":"lipo"}
+	]`
+	notecard := NotecardProfile{IdleMicroamps: 8, SessionMilliamps: 100, SessionSeconds: 60, GPSMilliamps: 25, GPSFixSeconds: 45, MotionMicroamps: 3}
+	host := HostProfile{SleepMicroamps: 10, ActiveMilliamps: 20, ActiveSeconds: 5, WakeMinutes: 10}
+	estimate, err := EstimatePower(requests, "NOTE-WBNA-500", notecard, host, 2000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !estimate.Valid || len(estimate.Requests) != 4 {
+		t.Fatalf("EstimatePower() = %+v", estimate)
+	}
+
+	// 24 hourly sessions of 60s at 100 mA, and a 45s GPS fix every 10 minutes at 25 mA
+	averages := map[string]float64{}
+	total := 0.0
+	for _, activity := range estimate.Activities {
+		averages[activity.Activity] = activity.AverageMilliamps
+		total += activity.AverageMilliamps
+	}
+	want := map[string]float64{"gps": 1.875, "sync_sessions": 1.6667, "host_active": 0.1667, "host_sleep": 0.0099, "notecard_idle": 0.008, "motion": 0.003}
+	for activity, average := range want {
+		if averages[activity] != average {
+			t.Errorf("EstimatePower() %s = %v mA, want %v", activity, averages[activity], average)
+		}
+	}
+	if estimate.Activities[0].Activity != "gps" || math.Abs(estimate.AverageMilliamps-total) > 0.001 {
+		t.Errorf("EstimatePower() activities = %+v, average %v", estimate.Activities, estimate.AverageMilliamps)
+	}
+	if estimate.BatteryLifeDays != roundTo(2000*0.85/estimate.AverageMilliamps/24, 1) {
+		t.Errorf("EstimatePower() battery life = %v days", estimate.BatteryLifeDays)
+	}
+	if !slices.Contains(estimate.Warnings, "card.voltage has no current figures, so it is not included") {
+		t.Errorf("EstimatePower() warnings = %v", estimate.Warnings)
+	}
+
+	// Every figure used records its source, and the usable battery fraction is labelled an assumption
+	sources := map[string]string{}
+	for _, figure := range estimate.Figures {
+		sources[figure.Name] = figure.Source
+	}
+	if len(sources) != 7 || sources["session_ma"] != "given" || !strings.HasPrefix(sources["battery_usable_fraction"], "assumption") {
+		t.Errorf("EstimatePower() figures = %+v", estimate.Figures)
+	}
+}
+
+func TestEstimatePowerWithoutFigures(t *testing.T) {
+	requests := `[{"req":"hub.set","mode":"periodic","outbound":60},{"req":"card.motion.mode","start":true}]`
+	estimate, err := EstimatePower(requests, "NOTE-NBGL-500", NotecardProfile{IdleMicroamps: 8}, HostProfile{}, 2000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the idle current is known, so sessions and the accelerometer are left out with a warning
+	if !estimate.Valid || len(estimate.Activities) != 1 || estimate.AverageMilliamps != 0.008 {
+		t.Errorf("EstimatePower() = %+v", estimate)
+	}
+	if !slices.Equal(estimate.Unestimated, []string{"sync sessions", "the accelerometer"}) || !strings.Contains(formatPowerEstimate(estimate), "Not included, as their figures are not known: sync sessions, the accelerometer.") {
+		t.Errorf("EstimatePower() unestimated = %v, warnings = %v", estimate.Unestimated, estimate.Warnings)
+	}
+
+	estimate, err = EstimatePower(requests, "", NotecardProfile{}, HostProfile{}, 2000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !estimate.Valid || len(estimate.Activities) != 0 || estimate.BatteryLifeDays != 0 || !strings.HasPrefix(formatPowerEstimate(estimate), "Power was not estimated") {
+		t.Errorf("EstimatePower() = %+v", estimate)
+	}
+}
+
+func TestPowerTableSources(t *testing.T) {
+	table, err := loadPowerTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hardware, figures := range table.Hardware {
+		for name, figure := range figures {
+			if figure.Value <= 0 || figure.Source == "" {
+				t.Errorf("power table figure %s of %s = %+v, want a value and the datasheet section it comes from", name, hardware, figure)
+			}
+		}
+	}
+	if table.BatteryUsableFraction.Value <= 0 || table.BatteryUsableFraction.Value > 1 || !strings.HasPrefix(table.BatteryUsableFraction.Source, "assumption") {
+		t.Errorf("power table battery_usable_fraction = %+v, want a fraction labelled as an assumption", table.BatteryUsableFraction)
+	}
+}
+
+func TestEstimatePowerValidatesRequests(t *testing.T) {
+	estimate, err := EstimatePower(`{"req":"hub.set","mode":"always"}`, "LORA", NotecardProfile{}, HostProfile{}, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Valid || estimate.Requests[0].Valid || len(estimate.Activities) != 0 {
+		t.Errorf("EstimatePower() = %+v", estimate)
+	}
+
+	if _, err := EstimatePower("", "NOTE-XYZ", NotecardProfile{}, HostProfile{}, 0, ""); err == nil {
+		t.Error("EstimatePower() accepted an unknown SKU")
+	}
+}
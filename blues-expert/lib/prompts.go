@@ -0,0 +1,153 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// promptSDK returns the lowercase SDK argument of a prompt, checking that documentation exists for it
+func promptSDK(request *mcp.GetPromptRequest) (string, error) {
+	sdk := strings.ToLower(strings.TrimSpace(request.Params.Arguments["sdk"]))
+	sdks := ListDocSDKs()
+	if sdk == "" {
+		return "", fmt.Errorf("sdk argument is required. Valid values are: %s", strings.Join(sdks, ", "))
+	}
+	if !slices.Contains(sdks, sdk) {
+		return "", fmt.Errorf("unknown sdk '%s'. Valid values are: %s", sdk, strings.Join(sdks, ", "))
+	}
+	return sdk, nil
+}
+
+// promptDocs returns the given documents of an SDK as embedded resources, skipping any that the
+// SDK does not have. The SDK's index is always included first.
+func promptDocs(sdk string, documentTypes ...string) []*mcp.PromptMessage {
+	var messages []*mcp.PromptMessage
+	for _, documentType := range append([]string{"index"}, documentTypes...) {
+		content, err := readDoc(sdk, documentType)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, &mcp.PromptMessage{
+			Role: "user",
+			Content: &mcp.EmbeddedResource{
+				Resource: &mcp.ResourceContents{
+					URI:      docURI(sdk, documentType),
+					MIMEType: "text/markdown",
+					Text:     string(content),
+				},
+			},
+		})
+	}
+	return messages
+}
+
+// promptResult assembles a prompt from its instructions followed by the reference documents
+func promptResult(description string, instructions string, documents []*mcp.PromptMessage) *mcp.GetPromptResult {
+	messages := []*mcp.PromptMessage{
+		{Role: "user", Content: &mcp.TextContent{Text: instructions}},
+	}
+	return &mcp.GetPromptResult{
+		Description: description,
+		Messages:    append(messages, documents...),
+	}
+}
+
+// HandleNewSensorProjectPrompt assembles the prompt for starting a new Notecard sensor project
+func HandleNewSensorProjectPrompt(ctx context.Context, request *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	sdk, err := promptSDK(request)
+	if err != nil {
+		return nil, err
+	}
+	args := request.Params.Arguments
+	sensor := strings.TrimSpace(args["sensor"])
+	if sensor == "" {
+		return nil, fmt.Errorf("sensor argument is required (e.g., 'BME280 temperature and humidity sensor')")
+	}
+	syncCadence := strings.TrimSpace(args["sync_cadence"])
+	if syncCadence == "" {
+		syncCadence = "every 60 minutes"
+	}
+	productUID := strings.TrimSpace(args["product_uid"])
+	if productUID == "" {
+		productUID = "a placeholder PRODUCT_UID define that I will fill in (e.g. \"com.my-company.my-name:my-project\")"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Create a new Notecard firmware project using the %s SDK that reads a %s and sends its readings to Notehub.\n\n", sdk, sensor)
+	fmt.Fprintf(&b, "- Product UID: %s\n", productUID)
+	fmt.Fprintf(&b, "- Sync cadence: sync readings to Notehub %s\n\n", syncCadence)
+	b.WriteString("Follow the attached SDK documentation. In particular:\n")
+	b.WriteString("1. Configure the Notecard with hub.set, choosing the mode and outbound/inbound intervals that match the sync cadence.\n")
+	b.WriteString("2. Define a Note template for the sensor readings with note.template before adding Notes.\n")
+	b.WriteString("3. Queue readings with note.add instead of syncing every reading, and let the Notecard sync on its own schedule.\n")
+	b.WriteString("4. Validate every Notecard request you write with the api_validate tool, and look up unfamiliar APIs with api_docs.\n")
+
+	return promptResult(
+		fmt.Sprintf("New %s Notecard project for a %s", sdk, sensor),
+		b.String(),
+		promptDocs(sdk, "best_practices", "sensors", "templates"),
+	), nil
+}
+
+// HandleDebugSyncPrompt assembles the prompt for debugging a Notecard that does not sync to Notehub
+func HandleDebugSyncPrompt(ctx context.Context, request *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	sdk, err := promptSDK(request)
+	if err != nil {
+		return nil, err
+	}
+	symptoms := strings.TrimSpace(request.Params.Arguments["symptoms"])
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "My Notecard, driven by firmware written with the %s SDK, is not syncing with Notehub.\n\n", sdk)
+	if symptoms != "" {
+		fmt.Fprintf(&b, "Symptoms: %s\n\n", symptoms)
+	}
+	b.WriteString("Help me find the cause step by step, using the attached SDK documentation:\n")
+	b.WriteString("1. Check that hub.set configures a valid product UID and a mode that allows syncing.\n")
+	b.WriteString("2. Use hub.status, hub.sync.status and card.wireless to see whether the Notecard is connected and why a sync failed.\n")
+	b.WriteString("3. Enable Notecard debug output or trace mode and explain what to look for in the log.\n")
+	b.WriteString("4. Review my firmware's Notecard requests with the api_validate or source_validate tools.\n")
+	b.WriteString("Use the docs_search tool for anything the guides do not cover.\n")
+
+	return promptResult(
+		fmt.Sprintf("Debug a %s Notecard project that won't sync", sdk),
+		b.String(),
+		promptDocs(sdk, "debugging", "connectivity", "best_practices"),
+	), nil
+}
+
+// HandleReducePowerPrompt assembles the prompt for reducing the power consumption of a Notecard project
+func HandleReducePowerPrompt(ctx context.Context, request *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	sdk, err := promptSDK(request)
+	if err != nil {
+		return nil, err
+	}
+	args := request.Params.Arguments
+	powerSource := strings.TrimSpace(args["power_source"])
+	syncCadence := strings.TrimSpace(args["sync_cadence"])
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Help me reduce the power consumption of my Notecard project written with the %s SDK.\n\n", sdk)
+	if powerSource != "" {
+		fmt.Fprintf(&b, "- Power source: %s\n", powerSource)
+	}
+	if syncCadence != "" {
+		fmt.Fprintf(&b, "- Required sync cadence: %s\n", syncCadence)
+	}
+	b.WriteString("\nUsing the attached SDK documentation:\n")
+	b.WriteString("1. Review the hub.set mode and sync intervals, preferring periodic or minimum mode and batching Notes.\n")
+	b.WriteString("2. Put the host to sleep between readings with card.attn sleep mode where the hardware allows it.\n")
+	b.WriteString("3. Configure card.voltage for the power source so the Notecard can back off syncing when the battery is low.\n")
+	b.WriteString("4. Point out anything in my firmware that keeps the radio, GPS or host awake unnecessarily.\n")
+	b.WriteString("Validate any Notecard requests you suggest with the api_validate tool.\n")
+
+	return promptResult(
+		fmt.Sprintf("Reduce power consumption of a %s Notecard project", sdk),
+		b.String(),
+		promptDocs(sdk, "power_management", "best_practices"),
+	), nil
+}
@@ -3,6 +3,7 @@ package lib
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,7 +11,6 @@ import (
 	"os"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -19,7 +19,7 @@ import (
 )
 
 const (
-	// BluesDocsAPIBaseURL is the base URL for the Blues documentation search API
+	// BluesDocsAPIBaseURL is the default base URL for the Blues documentation search API
 	BluesDocsAPIBaseURL = "https://ragpi.blues.tools/sources/blues-docs/search"
 )
 
@@ -73,25 +73,68 @@ type SearchResult struct {
 	Content   string `json:"content"`
 	URL       string `json:"url"`
 	CreatedAt string `json:"created_at"`
+	// Score is the relevance score assigned by the search backend, if it reports one
+	Score float64 `json:"score,omitempty"`
 }
 
-// SearchNotecardDocs performs a search against the Blues documentation API
-func SearchNotecardDocs(ctx context.Context, request *mcp.CallToolRequest, query string) (*mcp.CallToolResult, error) {
+// SearchNotecardDocs searches the documentation with the configured search backends. By default
+// the Blues documentation API is used, falling back to the local index of embedded documentation
+// and API schema if it cannot be reached.
+func SearchNotecardDocs(ctx context.Context, request *mcp.CallToolRequest, query string) (*mcp.CallToolResult, *DocsSearchOutput, error) {
+	searchResults, err := getSearchBackend().Search(ctx, request, query)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to search the documentation: %v", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	output := &DocsSearchOutput{Query: query, Results: searchResults}
+
+	// Format the response
+	if len(searchResults) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("No results found for query: '%s'", query)},
+			},
+		}, output, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: formatSearchResults(query, searchResults)},
+		},
+	}, output, nil
+}
+
+// ragpiBackend searches the Blues documentation API, or a ragpi mirror of it
+type ragpiBackend struct {
+	baseURL string
+}
+
+// Name identifies the ragpi backend
+func (b *ragpiBackend) Name() string {
+	return "ragpi"
+}
+
+// Search searches the ragpi documentation source. The API key is read from the BLUES_DOCS_API_KEY
+// environment variable, or from AWS Secrets Manager for the Blues documentation API.
+func (b *ragpiBackend) Search(ctx context.Context, request *mcp.CallToolRequest, query string) ([]SearchResult, error) {
+	if offlineMode && b.baseURL == BluesDocsAPIBaseURL {
+		return nil, fmt.Errorf("offline mode is enabled")
+	}
 
 	// Create HTTP client with timeout
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout: searchBackendTimeout,
 	}
 
 	// Build the search URL
-	searchURL, err := url.Parse(BluesDocsAPIBaseURL)
+	searchURL, err := url.Parse(b.baseURL)
 	if err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to parse search URL: %v", err)},
-			},
-			IsError: true,
-		}, nil
+		return nil, fmt.Errorf("failed to parse search URL: %v", err)
 	}
 
 	// Add query parameter
@@ -102,12 +145,7 @@ func SearchNotecardDocs(ctx context.Context, request *mcp.CallToolRequest, query
 	// Create request
 	req, err := http.NewRequestWithContext(ctx, "GET", searchURL.String(), nil)
 	if err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to create request: %v", err)},
-			},
-			IsError: true,
-		}, nil
+		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
 
 	// Add headers
@@ -116,7 +154,7 @@ func SearchNotecardDocs(ctx context.Context, request *mcp.CallToolRequest, query
 	// Get API key from AWS Secrets Manager or use Environment Variable
 	if os.Getenv("BLUES_DOCS_API_KEY") != "" {
 		req.Header.Set("x-api-key", os.Getenv("BLUES_DOCS_API_KEY"))
-	} else {
+	} else if b.baseURL == BluesDocsAPIBaseURL {
 		// Log that we're requesting permission to access the Blues documentation API
 		if request != nil && request.Session != nil {
 			request.Session.Log(ctx, &mcp.LoggingMessageParams{
@@ -127,12 +165,7 @@ func SearchNotecardDocs(ctx context.Context, request *mcp.CallToolRequest, query
 
 		apiKey, err := getAPIKeyFromAWS(ctx)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					&mcp.TextContent{Text: fmt.Sprintf("Failed to access the blues.dev documentation API: %v", err)},
-				},
-				IsError: true,
-			}, nil
+			return nil, fmt.Errorf("failed to access the blues.dev documentation API: %v", err)
 		}
 		req.Header.Set("x-api-key", apiKey)
 	}
@@ -148,20 +181,7 @@ func SearchNotecardDocs(ctx context.Context, request *mcp.CallToolRequest, query
 	// Make the request
 	resp, err := client.Do(req)
 	if err != nil {
-		// Log the error for server-side debugging
-		if request != nil && request.Session != nil {
-			request.Session.Log(ctx, &mcp.LoggingMessageParams{
-				Level: "error",
-				Data:  fmt.Sprintf("Ragpi request failed: %v", err),
-			})
-		}
-
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to make search request: %v", err)},
-			},
-			IsError: true,
-		}, nil
+		return nil, fmt.Errorf("failed to make search request: %v", err)
 	}
 	defer resp.Body.Close()
 
@@ -169,59 +189,30 @@ func SearchNotecardDocs(ctx context.Context, request *mcp.CallToolRequest, query
 	if resp.StatusCode != http.StatusOK {
 		// Try to read error response body for more details
 		body, _ := io.ReadAll(resp.Body)
-		errorMsg := fmt.Sprintf("Search API returned status %d", resp.StatusCode)
+		errorMsg := fmt.Sprintf("search API returned status %d", resp.StatusCode)
 		if len(body) > 0 {
 			errorMsg += fmt.Sprintf(": %s", string(body))
 		}
-
-		// Log the error for server-side debugging
-		if request != nil && request.Session != nil {
-			request.Session.Log(ctx, &mcp.LoggingMessageParams{
-				Level: "error",
-				Data:  fmt.Sprintf("Ragpi API error: %s", errorMsg),
-			})
-		}
-
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: errorMsg},
-			},
-			IsError: true,
-		}, nil
+		return nil, errors.New(errorMsg)
 	}
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to read response body: %v", err)},
-			},
-			IsError: true,
-		}, nil
+		return nil, fmt.Errorf("failed to read response body: %v", err)
 	}
 
 	// Parse JSON response as array of SearchResult
 	var searchResults []SearchResult
 	if err := json.Unmarshal(body, &searchResults); err != nil {
-		// If JSON parsing fails, return raw response
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Search Results for '%s':\n\n%s", query, string(body))},
-			},
-		}, nil
+		return nil, fmt.Errorf("failed to parse search response: %v", err)
 	}
 
-	// Format the response
-	if len(searchResults) == 0 {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("No results found for query: '%s'", query)},
-			},
-		}, nil
-	}
+	return searchResults, nil
+}
 
-	// Build formatted response
+// formatSearchResults renders search results as Markdown
+func formatSearchResults(query string, searchResults []SearchResult) string {
 	result := fmt.Sprintf("# Search Results for '%s'\n\nFound %d result(s):\n\n", query, len(searchResults))
 	for i, item := range searchResults {
 		result += fmt.Sprintf("## %d. %s\n\n", i+1, item.Title)
@@ -240,12 +231,7 @@ func SearchNotecardDocs(ctx context.Context, request *mcp.CallToolRequest, query
 		result += "\n\nCheck that the query is related to the response of the search. If not, the answer may not be available in the documentation. Suggest to the user to post a question on the Blues Discourse forum, https://discuss.blues.com/."
 
 	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: result},
-		},
-	}, nil
+	return result
 }
 
 // cleanContent cleans up and formats the content from search results
@@ -0,0 +1,157 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// URLs of a tagged notecard-schema release. The main schema is a release asset, while the request
+// schemas it references are read from the repository at the release tag.
+const (
+	schemaReleaseURLFormat = "https://github.com/blues/notecard-schema/releases/download/v%s/notecard.api.json"
+	schemaRawURLFormat     = "https://raw.githubusercontent.com/blues/notecard-schema/v%s/"
+)
+
+// schemaMainFile is the filename of the main Notecard API schema
+const schemaMainFile = "notecard.api.json"
+
+// schemaVersionPattern restricts schema versions to names that are safe to use as directory names
+var schemaVersionPattern = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._-]*$`)
+
+// schemaRelease is a version of the Notecard API schema, along with its cache directory and compiled schema
+type schemaRelease struct {
+	// version is the pinned schema version, or "" for the latest release
+	version string
+	// url is the URL of the main schema
+	url string
+	// dir is the directory holding the main and request schema files
+	dir string
+	// refBase, if set, is the base URL referenced request schemas are fetched from
+	refBase string
+	// diskDir, if set, is a directory the schema is loaded from instead of the network
+	diskDir string
+
+	mutex    sync.RWMutex
+	once     sync.Once
+	compiled *jsonschema.Schema
+	err      error
+}
+
+// schemaRegistry holds every schema release in use, keyed by main schema URL
+var (
+	schemaRegistry      = make(map[string]*schemaRelease)
+	schemaRegistryMutex sync.Mutex
+)
+
+// schemaDir is a directory of schema versions to load from disk, one subdirectory per version
+var schemaDir string
+
+// SetSchemaDir sets a directory of Notecard API schema versions to load from disk. Each version is
+// a subdirectory (e.g. "0.2.1/") containing notecard.api.json and the request schemas it references.
+func SetSchemaDir(dir string) {
+	schemaDir = dir
+}
+
+// ResolveSchemaVersion returns the main schema URL for a Notecard API schema version, registering the
+// version if needed. An empty version or "latest" selects the latest release.
+func ResolveSchemaVersion(version string) (string, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if version == "" || version == "latest" {
+		return defaultSchemaURL, nil
+	}
+	if !schemaVersionPattern.MatchString(version) {
+		return "", fmt.Errorf("invalid schema version '%s'", version)
+	}
+
+	release := &schemaRelease{version: version}
+	if diskDir := filepath.Join(schemaDir, version); schemaDir != "" && fileExists(filepath.Join(diskDir, schemaMainFile)) {
+		release.url = "file://" + filepath.Join(diskDir, schemaMainFile)
+		release.dir = diskDir
+		release.diskDir = diskDir
+	} else if offlineMode && version != bundledSchemaVersion() {
+		return "", fmt.Errorf("schema version '%s' is not available offline. Available versions are: %s", version, strings.Join(AvailableSchemaVersions(), ", "))
+	} else {
+		release.url = fmt.Sprintf(schemaReleaseURLFormat, version)
+		release.dir = filepath.Join(cacheDir, version)
+		release.refBase = fmt.Sprintf(schemaRawURLFormat, version)
+	}
+
+	schemaRegistryMutex.Lock()
+	defer schemaRegistryMutex.Unlock()
+	if _, ok := schemaRegistry[release.url]; !ok {
+		schemaRegistry[release.url] = release
+	}
+	return release.url, nil
+}
+
+// schemaReleaseForURL returns the registered schema release for a main schema URL. Unregistered
+// URLs, including the default, are treated as the latest release and cached in the top-level cache directory.
+func schemaReleaseForURL(url string) *schemaRelease {
+	if url == "" {
+		url = defaultSchemaURL
+	}
+
+	schemaRegistryMutex.Lock()
+	defer schemaRegistryMutex.Unlock()
+	release, ok := schemaRegistry[url]
+	if !ok {
+		release = &schemaRelease{url: url, dir: cacheDir}
+		schemaRegistry[url] = release
+	}
+	return release
+}
+
+// AvailableSchemaVersions lists the schema versions that can be used without the network:
+// those in the schema directory and the bundled snapshot
+func AvailableSchemaVersions() []string {
+	versions := []string{"latest"}
+	if schemaDir != "" {
+		entries, err := os.ReadDir(schemaDir)
+		if err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() && fileExists(filepath.Join(schemaDir, entry.Name(), schemaMainFile)) {
+					versions = append(versions, entry.Name())
+				}
+			}
+		}
+	}
+	if version := bundledSchemaVersion(); version != "" && !slices.Contains(versions, version) {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions[1:])
+	return versions
+}
+
+// bundledSchemaVersion returns the version of the bundled schema snapshot
+func bundledSchemaVersion() string {
+	data, err := schemaSnapshot.ReadFile("schema/" + schemaMainFile)
+	if err != nil {
+		return ""
+	}
+	var schemaMap map[string]interface{}
+	if err := json.Unmarshal(data, &schemaMap); err != nil {
+		return ""
+	}
+	version, _ := schemaMap["version"].(string)
+	return version
+}
+
+// requestSchemaPath returns the path of the request schema for an API within the release
+func (r *schemaRelease) requestSchemaPath(apiName string) string {
+	return filepath.Join(r.dir, apiName+".req.notecard.api.json")
+}
+
+// fileExists reports whether a regular file exists at path
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
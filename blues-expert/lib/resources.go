@@ -0,0 +1,81 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// DocsResourcePrefix is the URI prefix of the embedded firmware documentation resources
+	DocsResourcePrefix = "blues://docs/"
+	// APIResourcePrefix is the URI prefix of the Notecard API schema resources
+	APIResourcePrefix = "blues://api/"
+)
+
+// ListAPIResourceNames returns the name of every API in the latest Notecard API schema, sorted
+func ListAPIResourceNames() ([]string, error) {
+	release := schemaReleaseForURL(defaultSchemaURL)
+	if err := release.init(); err != nil {
+		return nil, err
+	}
+	names := cachedAPINames(release.dir)
+	sort.Strings(names)
+	return names, nil
+}
+
+// HandleDocResource reads a firmware document resource, e.g. blues://docs/arduino/power_management
+func HandleDocResource(ctx context.Context, request *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := request.Params.URI
+	sdk, documentType, ok := strings.Cut(strings.TrimPrefix(uri, DocsResourcePrefix), "/")
+	if !strings.HasPrefix(uri, DocsResourcePrefix) || !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	content, err := readDoc(sdk, documentType)
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{URI: uri, MIMEType: "text/markdown", Text: string(content)},
+		},
+	}, nil
+}
+
+// HandleAPIResource reads the request schema of a Notecard API resource, e.g. blues://api/card.voltage
+func HandleAPIResource(ctx context.Context, request *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := request.Params.URI
+	apiName := strings.TrimPrefix(uri, APIResourcePrefix)
+	if !strings.HasPrefix(uri, APIResourcePrefix) || !isDocPathElement(apiName) {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	release := schemaReleaseForURL(defaultSchemaURL)
+	if err := release.init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize schema: %v", err)
+	}
+	content, err := os.ReadFile(release.requestSchemaPath(apiName))
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{
+				URI:      uri,
+				MIMEType: "application/schema+json",
+				Text:     string(content),
+				Meta: mcp.Meta{
+					"schema_version": GetSchemaVersion(defaultSchemaURL),
+					"schema_source":  GetSchemaSource(defaultSchemaURL),
+				},
+			},
+		},
+	}, nil
+}
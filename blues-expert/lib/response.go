@@ -0,0 +1,237 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// commonResponseProperties may be returned by any request: 'err' when it fails, and 'id' and 'crc'
+// when they were sent with the request. They are not listed by the response schemas.
+var commonResponseProperties = []string{"crc", "err", "id"}
+
+// ResponseValidationError is returned when a response does not conform to the response schema of
+// its request. It holds every violation found, not just the first.
+type ResponseValidationError struct {
+	ReqType  string              `json:"request"`
+	Findings []ValidationFinding `json:"findings"`
+}
+
+// Error formats the findings into a single user-friendly message
+func (e *ResponseValidationError) Error() string {
+	messages := make([]string, 0, len(e.Findings))
+	for _, finding := range e.Findings {
+		if property := strings.TrimPrefix(finding.Pointer, "/"); property != "" {
+			messages = append(messages, fmt.Sprintf("'%s' is not valid in a %s response: %s", property, e.ReqType, finding.Message))
+		} else {
+			messages = append(messages, fmt.Sprintf("for a '%s' response %s", e.ReqType, finding.Message))
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateNotecardResponse validates a Notecard response against the response schema of the request
+// it answers, e.g. a note.add response against note.add.rsp.notecard.api.json
+func ValidateNotecardResponse(reqType string, rspMap map[string]interface{}, schemaURL string) error {
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+	if err := initSchema(schemaURL); err != nil {
+		return fmt.Errorf("failed to initialize schema: %v", err)
+	}
+
+	release := schemaReleaseForURL(schemaURL)
+	schema, err := release.compileResponseSchema(reqType)
+	if err != nil {
+		return err
+	}
+
+	var findings []ValidationFinding
+	if err := schema.Validate(rspMap); err != nil {
+		var ve *jsonschema.ValidationError
+		if !errors.As(err, &ve) {
+			return err
+		}
+		findings = collectFindings(ve, rspMap)
+	}
+	if value, ok := rspMap["err"]; ok {
+		if _, isString := value.(string); !isString {
+			findings = append(findings, ValidationFinding{Pointer: "/err", Value: value, Keyword: "type", Message: "expected string, but got " + jsonTypeName(value)})
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	// Keep findings in a stable order, as schema properties are validated in map order
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Pointer < findings[j].Pointer
+	})
+	return &ResponseValidationError{ReqType: reqType, Findings: findings}
+}
+
+// CheckNotecardResponse validates a captured Notecard response, given as JSON, against the response
+// schema of its request, given as an API name (e.g. 'note.add') or as the JSON request. Properties the
+// response schema does not document are reported separately, as they do not make the response invalid.
+// An error is only returned if the request or response cannot be read.
+func CheckNotecardResponse(request string, response string, schemaURL string) (*ResponseValidationResult, error) {
+	reqType, err := responseRequestType(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var rspMap map[string]interface{}
+	if trimmed := strings.TrimSpace(response); !strings.HasPrefix(trimmed, "{") {
+		return nil, fmt.Errorf("response must be a JSON object")
+	} else if err := json.Unmarshal([]byte(trimmed), &rspMap); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %v", err)
+	}
+
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+	result := &ResponseValidationResult{Request: reqType}
+	result.NotecardError, _ = rspMap["err"].(string)
+	if err := ValidateNotecardResponse(reqType, rspMap, schemaURL); err != nil {
+		var validationErr *ResponseValidationError
+		if !errors.As(err, &validationErr) {
+			return nil, err
+		}
+		result.Findings = validationErr.Findings
+	} else {
+		result.Valid = true
+	}
+
+	schemaMap, responseSchemaSource, err := schemaReleaseForURL(schemaURL).loadResponseSchema(reqType)
+	if err != nil {
+		return nil, err
+	}
+	result.ResponseSchemaSource = responseSchemaSource
+	documented, _ := schemaMap["properties"].(map[string]interface{})
+	properties := sortedKeys(documented)
+	for _, name := range sortedKeys(rspMap) {
+		if slices.Contains(properties, name) || slices.Contains(commonResponseProperties, name) {
+			continue
+		}
+		undocumented := ValidationFinding{
+			Pointer: "/" + strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1"),
+			Value:   rspMap[name],
+			Keyword: "properties",
+			Message: fmt.Sprintf("'%s' is not documented for %s responses", name, reqType),
+		}
+		if suggestion := suggestClosest(name, properties); suggestion != "" {
+			undocumented.Suggestion = suggestion
+			undocumented.Message += fmt.Sprintf(" — did you mean '%s'?", suggestion)
+		}
+		result.Undocumented = append(result.Undocumented, undocumented)
+	}
+
+	result.SchemaVersion = GetSchemaVersion(schemaURL)
+	result.SchemaSource = GetSchemaSource(schemaURL)
+	return result, nil
+}
+
+// responseRequestType returns the name of the request a response answers, given as an API name or
+// as the JSON request. Commands have no response.
+func responseRequestType(request string) (string, error) {
+	trimmed := strings.TrimSpace(request)
+	if !strings.HasPrefix(trimmed, "{") {
+		if trimmed == "" {
+			return "", fmt.Errorf("no request type specified")
+		}
+		return trimmed, nil
+	}
+
+	var reqMap map[string]interface{}
+	if err := json.Unmarshal([]byte(trimmed), &reqMap); err != nil {
+		return "", fmt.Errorf("invalid JSON request: %v", err)
+	}
+	if reqType, ok := reqMap["req"].(string); ok && reqType != "" {
+		return reqType, nil
+	}
+	if cmdType, ok := reqMap["cmd"].(string); ok && cmdType != "" {
+		return "", fmt.Errorf("'%s' was sent as a command, and commands have no response", cmdType)
+	}
+	return "", fmt.Errorf("no request type specified")
+}
+
+// compileResponseSchema compiles the response schema of an API
+func (r *schemaRelease) compileResponseSchema(apiName string) (*jsonschema.Schema, error) {
+	if _, err := os.Stat(r.requestSchemaPath(apiName)); os.IsNotExist(err) {
+		if suggestion := suggestClosest(apiName, cachedAPINames(r.dir)); suggestion != "" {
+			return nil, fmt.Errorf("unknown request '%s' — did you mean '%s'?", apiName, suggestion)
+		}
+		return nil, fmt.Errorf("unknown request type: %s", apiName)
+	}
+
+	schemaMap, _, err := r.loadResponseSchema(apiName)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := schemaMap["$id"].(string)
+	data, err := json.Marshal(schemaMap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response schema of %s: %v", apiName, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	if err := compiler.AddResource(id, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to add response schema of %s: %v", apiName, err)
+	}
+	schema, err := compiler.Compile(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile response schema of %s: %v", apiName, err)
+	}
+	return schema, nil
+}
+
+// jsonTypeName returns the JSON type of a decoded JSON value
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// formatResponseValidation summarizes a response validation
+func formatResponseValidation(result *ResponseValidationResult) string {
+	var b strings.Builder
+	if result.Valid {
+		fmt.Fprintf(&b, "Response validation successful: the response is valid according to the %s response schema.", result.Request)
+	} else {
+		fmt.Fprintf(&b, "Response validation failed: %d problem(s) found in the '%s' response:", len(result.Findings), result.Request)
+		for _, finding := range result.Findings {
+			fmt.Fprintf(&b, "\n- %s: %s", finding.Pointer, finding.Message)
+		}
+	}
+	if result.NotecardError != "" {
+		fmt.Fprintf(&b, "\nThe Notecard returned an error: %s", result.NotecardError)
+	}
+	if result.ResponseSchemaSource == schemaSourceSynthesized {
+		fmt.Fprintf(&b, "\nThe %s response schema is a synthesized stand-in bundled with this server, not part of the Notecard API schema.", result.Request)
+	}
+	if len(result.Undocumented) > 0 {
+		b.WriteString("\nNot documented by the response schema:")
+		for _, finding := range result.Undocumented {
+			fmt.Fprintf(&b, "\n- %s", finding.Message)
+		}
+	}
+	return b.String()
+}
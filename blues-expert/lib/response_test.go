@@ -0,0 +1,57 @@
+package lib
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateNotecardResponse(t *testing.T) {
+	if err := ValidateNotecardResponse("note.add", map[string]interface{}{"total": float64(3)}, ""); err != nil {
+		t.Errorf("ValidateNotecardResponse() error = %v", err)
+	}
+	if err := ValidateNotecardResponse("note.add", map[string]interface{}{"err": "no notefile {note-noexist}"}, ""); err != nil {
+		t.Errorf("ValidateNotecardResponse() rejected an error response: %v", err)
+	}
+
+	err := ValidateNotecardResponse("note.add", map[string]interface{}{"total": "3", "err": true}, "")
+	var validationErr *ResponseValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("ValidateNotecardResponse() error = %v, want a ResponseValidationError", err)
+	}
+	if len(validationErr.Findings) != 2 || validationErr.Findings[0].Pointer != "/err" || validationErr.Findings[1].Pointer != "/total" {
+		t.Errorf("ValidateNotecardResponse() findings = %+v", validationErr.Findings)
+	}
+
+	if err := ValidateNotecardResponse("note.addd", map[string]interface{}{}, ""); err == nil || errors.As(err, &validationErr) {
+		t.Errorf("ValidateNotecardResponse() error = %v, want an unknown request error", err)
+	}
+}
+
+func TestCheckNotecardResponse(t *testing.T) {
+	result, err := CheckNotecardResponse(`{"req":"card.version"}`, `{"version":"notecard-9.1.1.17004","api":9,"device":"dev:000000000000000","bord":"1.11"}`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Request != "card.version" || len(result.Undocumented) != 1 || result.Undocumented[0].Suggestion != "board" {
+		t.Errorf("CheckNotecardResponse() = %+v", result)
+	}
+
+	result, err = CheckNotecardResponse("card.temp", `{"value":"27.6","id":3,"err":"sensor unavailable"}`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || len(result.Findings) != 1 || result.NotecardError != "sensor unavailable" || len(result.Undocumented) != 0 {
+		t.Errorf("CheckNotecardResponse() = %+v", result)
+	}
+
+	for _, tt := range []struct{ request, response string }{
+		{`{"cmd":"hub.sync"}`, `{}`},
+		{"", `{}`},
+		{"card.version", `[]`},
+		{"card.version", `{"version":`},
+	} {
+		if _, err := CheckNotecardResponse(tt.request, tt.response, ""); err == nil {
+			t.Errorf("CheckNotecardResponse(%q, %q) accepted an unreadable input", tt.request, tt.response)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// bm25K1 controls how quickly repeated terms stop adding to a section's score
+	bm25K1 = 1.2
+	// bm25B controls how strongly scores are normalized by section length
+	bm25B = 0.75
+	// localSearchLimit is the maximum number of results returned by the local index
+	localSearchLimit = 5
+	// notecardAPIReferenceURL is the base URL of the Notecard API reference on blues.dev
+	notecardAPIReferenceURL = "https://dev.blues.io/api-reference/notecard-api/"
+)
+
+// searchStopWords are common words that carry no meaning in a search query
+var searchStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"can": true, "do": true, "does": true, "for": true, "from": true, "how": true, "i": true, "if": true,
+	"in": true, "is": true, "it": true, "my": true, "of": true, "on": true, "or": true, "should": true,
+	"that": true, "the": true, "this": true, "to": true, "use": true, "what": true, "when": true,
+	"which": true, "why": true, "with": true, "you": true,
+}
+
+var (
+	searchTokenPattern   = regexp.MustCompile(`[a-z0-9_.']+`)
+	markdownHeading      = regexp.MustCompile(`^(#{1,3})\s+(.+?)\s*#*$`)
+	anchorInvalidPattern = regexp.MustCompile(`[^a-z0-9 _-]`)
+)
+
+// searchSection is a searchable chunk of documentation, such as a Markdown section or an API
+type searchSection struct {
+	result SearchResult
+	terms  map[string]int
+	length int
+}
+
+// searchIndex is an in-memory BM25 index over the embedded documentation and API schema
+type searchIndex struct {
+	sections      []searchSection
+	docFreq       map[string]int
+	averageLength float64
+}
+
+var (
+	localIndex      *searchIndex
+	localIndexMutex sync.Mutex
+)
+
+// BuildLocalSearchIndex builds the local search index used when the documentation search API
+// is unavailable. It is built on first use if not called at startup, and rebuilt on first use
+// after the documentation is reloaded.
+func BuildLocalSearchIndex() {
+	currentSearchIndex()
+}
+
+// currentSearchIndex returns the local search index, building it if needed
+func currentSearchIndex() *searchIndex {
+	localIndexMutex.Lock()
+	defer localIndexMutex.Unlock()
+	if localIndex == nil {
+		sections := markdownSearchSections(docFiles())
+		apiSections, err := apiSearchSections(defaultSchemaURL)
+		if err != nil {
+			log.Warn().Err(err).Msg("Local search index built without Notecard API schema")
+		}
+		localIndex = newSearchIndex(append(sections, apiSections...))
+		log.Info().Int("sections", len(localIndex.sections)).Msg("Local search index built")
+	}
+	return localIndex
+}
+
+// resetLocalSearchIndex discards the local search index so that it is rebuilt on next use
+func resetLocalSearchIndex() {
+	localIndexMutex.Lock()
+	localIndex = nil
+	localIndexMutex.Unlock()
+}
+
+// SearchLocalDocs searches the local index of firmware documentation and Notecard API schema
+func SearchLocalDocs(query string) []SearchResult {
+	return currentSearchIndex().search(query, localSearchLimit)
+}
+
+// newSearchIndex indexes the given sections
+func newSearchIndex(sections []searchSection) *searchIndex {
+	index := &searchIndex{sections: sections, docFreq: make(map[string]int)}
+	totalLength := 0
+	for _, section := range sections {
+		totalLength += section.length
+		for term := range section.terms {
+			index.docFreq[term]++
+		}
+	}
+	if len(sections) > 0 {
+		index.averageLength = float64(totalLength) / float64(len(sections))
+	}
+	return index
+}
+
+// search returns up to limit sections ranked by their BM25 score for the query
+func (idx *searchIndex) search(query string, limit int) []SearchResult {
+	queryTerms := searchTerms(query)
+	if len(queryTerms) == 0 || len(idx.sections) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	var matches []scored
+	n := float64(len(idx.sections))
+	for i, section := range idx.sections {
+		score := 0.0
+		for term := range queryTerms {
+			tf := float64(section.terms[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(section.length)/idx.averageLength
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+		if score > 0 {
+			matches = append(matches, scored{index: i, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	results := make([]SearchResult, 0, len(matches))
+	for _, match := range matches {
+		result := idx.sections[match.index].result
+		result.Score = math.Round(match.score*1000) / 1000
+		results = append(results, result)
+	}
+	return results
+}
+
+// newSearchSection tokenizes a section. Title terms are counted twice so that headings rank higher.
+func newSearchSection(result SearchResult) searchSection {
+	section := searchSection{result: result, terms: make(map[string]int)}
+	for _, text := range []string{result.Title, result.Title, result.Content} {
+		for _, term := range tokenize(text) {
+			section.terms[term]++
+			section.length++
+		}
+	}
+	return section
+}
+
+// searchTerms returns the distinct terms of a query
+func searchTerms(query string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, term := range tokenize(query) {
+		terms[term] = true
+	}
+	return terms
+}
+
+// tokenize splits text into lowercase terms without stop words. Dotted API names such as
+// "card.voltage" are kept whole and also split into their parts.
+func tokenize(text string) []string {
+	var terms []string
+	for _, token := range searchTokenPattern.FindAllString(strings.ToLower(text), -1) {
+		token = strings.ReplaceAll(strings.TrimSuffix(token, "'s"), "'", "")
+		token = strings.Trim(token, "._")
+		if token == "" {
+			continue
+		}
+		if strings.Contains(token, ".") {
+			terms = append(terms, token)
+			for _, part := range strings.Split(token, ".") {
+				if part != "" && !searchStopWords[part] {
+					terms = append(terms, stemTerm(part))
+				}
+			}
+			continue
+		}
+		if !searchStopWords[token] {
+			terms = append(terms, stemTerm(token))
+		}
+	}
+	return terms
+}
+
+// stemTerm strips common plural suffixes so that e.g. "sensors" matches "sensor"
+func stemTerm(term string) string {
+	switch {
+	case len(term) > 4 && strings.HasSuffix(term, "ies"):
+		return term[:len(term)-3] + "y"
+	case len(term) > 3 && strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss"):
+		return term[:len(term)-1]
+	}
+	return term
+}
+
+// headingAnchor converts a Markdown heading to its anchor, e.g. "Sleep Modes" -> "sleep-modes"
+func headingAnchor(heading string) string {
+	anchor := anchorInvalidPattern.ReplaceAllString(strings.ToLower(heading), "")
+	return strings.ReplaceAll(strings.TrimSpace(anchor), " ", "-")
+}
+
+// markdownSearchSections splits every firmware document into one section per heading
+func markdownSearchSections(library map[string]string) []searchSection {
+	var sections []searchSection
+	for _, key := range docKeys(library) {
+		_, body := parseFrontMatter(library[key])
+		for _, result := range splitMarkdownSections(DocsResourcePrefix+key, body) {
+			sections = append(sections, newSearchSection(result))
+		}
+	}
+	return sections
+}
+
+// splitMarkdownSections splits a Markdown document at its headings, ignoring lines in code blocks.
+// Each section is linked to the document's resource URI with the heading's anchor.
+func splitMarkdownSections(uri string, content string) []SearchResult {
+	var results []SearchResult
+	documentTitle := path.Base(uri)
+	heading := ""
+	var body strings.Builder
+	inCodeBlock := false
+
+	flush := func() {
+		text := strings.TrimSpace(body.String())
+		body.Reset()
+		if text == "" {
+			return
+		}
+		title := documentTitle
+		url := uri
+		if heading != "" && heading != documentTitle {
+			title = fmt.Sprintf("%s: %s", documentTitle, heading)
+			url = fmt.Sprintf("%s#%s", uri, headingAnchor(heading))
+		}
+		results = append(results, SearchResult{
+			ID:      url,
+			Title:   title,
+			Content: text,
+			URL:     url,
+		})
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+		}
+		if !inCodeBlock {
+			if match := markdownHeading.FindStringSubmatch(line); match != nil {
+				flush()
+				heading = match[2]
+				if match[1] == "#" && len(results) == 0 {
+					documentTitle = heading
+				}
+				continue
+			}
+		}
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	flush()
+	return results
+}
+
+// apiSearchSections indexes the description, properties and samples of every API in the schema
+func apiSearchSections(schemaURL string) ([]searchSection, error) {
+	release := schemaReleaseForURL(schemaURL)
+	if err := release.init(); err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(release.dir, "*.req.notecard.api.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find schema files: %v", err)
+	}
+
+	var sections []searchSection
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".req.notecard.api.json")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var schemaData map[string]interface{}
+		if err := json.Unmarshal(data, &schemaData); err != nil {
+			continue
+		}
+		api := extractAPIFromSchema(name, schemaData)
+		sections = append(sections, newSearchSection(SearchResult{
+			ID:      "api:" + name,
+			Title:   fmt.Sprintf("%s API", name),
+			Content: apiSearchContent(api),
+			URL:     apiReferenceURL(name),
+		}))
+	}
+	return sections, nil
+}
+
+// apiSearchContent renders the searchable text of an API
+func apiSearchContent(api *APIEntry) string {
+	var builder strings.Builder
+	builder.WriteString(api.Description)
+	if api.Annotation != "" {
+		builder.WriteString(" ")
+		builder.WriteString(api.Annotation)
+	}
+
+	names := make([]string, 0, len(api.Properties))
+	for name := range api.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property := api.Properties[name]
+		fmt.Fprintf(&builder, " %s: %s", name, property.Description)
+		for _, subDesc := range property.SubDescriptions {
+			fmt.Fprintf(&builder, " %s: %s", subDesc.Const, subDesc.Description)
+		}
+	}
+
+	for _, sample := range api.Samples {
+		fmt.Fprintf(&builder, " %s. %s %s", sample.Title, sample.Description, sample.JSON)
+	}
+	return builder.String()
+}
+
+// apiReferenceURL links an API to its section of the Notecard API reference on blues.dev,
+// e.g. "card.voltage" -> ".../card-requests/#card-voltage"
+func apiReferenceURL(apiName string) string {
+	namespace, _, _ := strings.Cut(apiName, ".")
+	return fmt.Sprintf("%s%s-requests/#%s", notecardAPIReferenceURL, namespace, strings.ReplaceAll(apiName, ".", "-"))
+}
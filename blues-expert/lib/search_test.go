@@ -0,0 +1,66 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitMarkdownSections(t *testing.T) {
+	content := "# Power Management\n\nIntro text.\n\n## Sleep Modes\n\nUse card.attn to sleep.\n\n```c\n# not a heading\n```\n\n### Waking (GPIO)\n\nWake on a pin.\n"
+	got := splitMarkdownSections("blues://docs/c/power", content)
+
+	var urls []string
+	for _, section := range got {
+		urls = append(urls, section.URL)
+	}
+	want := []string{
+		"blues://docs/c/power",
+		"blues://docs/c/power#sleep-modes",
+		"blues://docs/c/power#waking-gpio",
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Fatalf("splitMarkdownSections() urls = %v, want %v", urls, want)
+	}
+	if got[1].Title != "Power Management: Sleep Modes" {
+		t.Errorf("splitMarkdownSections() title = %q", got[1].Title)
+	}
+	if got[1].Content != "Use card.attn to sleep.\n\n```c\n# not a heading\n```" {
+		t.Errorf("splitMarkdownSections() kept code block heading out of content: %q", got[1].Content)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("How do I read the Notecard's sensors with card.voltage?")
+	want := []string{"read", "notecard", "sensor", "card.voltage", "card", "voltage"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize() = %v, want %v", got, want)
+	}
+}
+
+func TestSearchIndexRanking(t *testing.T) {
+	index := newSearchIndex([]searchSection{
+		newSearchSection(SearchResult{ID: "gps", Title: "GPS", Content: "Use card.location.mode to enable GPS tracking periodically."}),
+		newSearchSection(SearchResult{ID: "power", Title: "Power Management", Content: "Reduce power by sleeping the host. Power draw matters for battery devices."}),
+		newSearchSection(SearchResult{ID: "sync", Title: "Syncing", Content: "Use hub.sync to sync notes with Notehub."}),
+	})
+
+	results := index.search("low power battery", 5)
+	if len(results) != 1 || results[0].ID != "power" {
+		t.Errorf("search() = %v, want only the power section", results)
+	}
+
+	results = index.search("card.location.mode", 5)
+	if len(results) == 0 || results[0].ID != "gps" {
+		t.Errorf("search() = %v, want the gps section first", results)
+	}
+
+	if results := index.search("the and of", 5); len(results) != 0 {
+		t.Errorf("search() of stop words = %v, want no results", results)
+	}
+}
+
+func TestAPIReferenceURL(t *testing.T) {
+	if got, want := apiReferenceURL("card.location.mode"), "https://dev.blues.io/api-reference/notecard-api/card-requests/#card-location-mode"; got != want {
+		t.Errorf("apiReferenceURL() = %q, want %q", got, want)
+	}
+}
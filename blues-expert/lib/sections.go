@@ -0,0 +1,152 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// docSection is a heading of a Markdown document and the lines it covers. A section ends at the next
+// heading of the same or a higher level, so it includes its subsections; its own text ends at the
+// next heading of any level.
+type docSection struct {
+	Level   int
+	Heading string
+	Anchor  string
+	start   int
+	ownEnd  int
+	end     int
+}
+
+// parseDocSections returns the level 1 to 3 headings of a Markdown document, ignoring code blocks
+func parseDocSections(lines []string) []docSection {
+	var sections []docSection
+	inCodeBlock := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		if match := markdownHeading.FindStringSubmatch(line); match != nil {
+			sections = append(sections, docSection{
+				Level:   len(match[1]),
+				Heading: match[2],
+				Anchor:  headingAnchor(match[2]),
+				start:   i,
+			})
+		}
+	}
+
+	for i := range sections {
+		sections[i].ownEnd = len(lines)
+		sections[i].end = len(lines)
+		if i+1 < len(sections) {
+			sections[i].ownEnd = sections[i+1].start
+		}
+		for _, next := range sections[i+1:] {
+			if next.Level <= sections[i].Level {
+				sections[i].end = next.start
+				break
+			}
+		}
+	}
+	return sections
+}
+
+// docOutline returns the headings of a document and the number of lines in each section
+func docOutline(content string) []DocHeading {
+	var headings []DocHeading
+	for _, section := range parseDocSections(strings.Split(content, "\n")) {
+		headings = append(headings, DocHeading{
+			Level:   section.Level,
+			Heading: section.Heading,
+			Anchor:  section.Anchor,
+			Lines:   section.end - section.start,
+		})
+	}
+	return headings
+}
+
+// docTableOfContents renders the headings of a document as a Markdown list of anchors
+func docTableOfContents(sdk string, documentType string, headings []DocHeading) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Table of contents: %s/%s\n\n", sdk, documentType)
+	for _, heading := range headings {
+		fmt.Fprintf(&b, "%s- [%s](#%s) (%d lines)\n", strings.Repeat("  ", heading.Level-1), heading.Heading, heading.Anchor, heading.Lines)
+	}
+	b.WriteString("\nPass one of these anchors as 'section' to retrieve just that section.")
+	return b.String()
+}
+
+// selectDocSections returns the Markdown of the sections matching a heading or anchor and, if a
+// keyword is given, only the parts of them whose own text mentions the keyword
+func selectDocSections(content string, section string, keyword string) (string, error) {
+	lines := strings.Split(content, "\n")
+	sections := parseDocSections(lines)
+
+	// Sections are matched by anchor or heading, falling back to headings containing the text
+	var selected []docSection
+	if section != "" {
+		wanted := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(section), "#"))
+		for _, s := range sections {
+			if s.Anchor == wanted || strings.ToLower(s.Heading) == wanted {
+				selected = append(selected, s)
+			}
+		}
+		if len(selected) == 0 {
+			for _, s := range sections {
+				if strings.Contains(strings.ToLower(s.Heading), wanted) {
+					selected = append(selected, s)
+				}
+			}
+		}
+		if len(selected) == 0 {
+			var anchors []string
+			for _, s := range sections {
+				anchors = append(anchors, s.Anchor)
+			}
+			if suggestion := suggestClosest(headingAnchor(wanted), anchors); suggestion != "" {
+				return "", fmt.Errorf("no section matches '%s' — did you mean '%s'?", section, suggestion)
+			}
+			return "", fmt.Errorf("no section matches '%s'. Use toc=true to list the sections of this document", section)
+		}
+	} else {
+		selected = []docSection{{start: 0, end: len(lines)}}
+	}
+
+	var parts []string
+	if keyword == "" {
+		for _, s := range selected {
+			parts = append(parts, strings.TrimSpace(strings.Join(lines[s.start:s.end], "\n")))
+		}
+		return strings.Join(parts, "\n\n"), nil
+	}
+
+	// Return each heading within the selected sections whose own text mentions the keyword
+	needle := strings.ToLower(keyword)
+	for _, s := range sections {
+		if !withinSections(s, selected) {
+			continue
+		}
+		text := strings.Join(lines[s.start:s.ownEnd], "\n")
+		if strings.Contains(strings.ToLower(text), needle) {
+			parts = append(parts, strings.TrimSpace(text))
+		}
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("no section mentions '%s'. Use toc=true to list the sections of this document", keyword)
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// withinSections reports whether a section lies inside any of the given sections
+func withinSections(section docSection, sections []docSection) bool {
+	for _, s := range sections {
+		if section.start >= s.start && section.start < s.end {
+			return true
+		}
+	}
+	return false
+}
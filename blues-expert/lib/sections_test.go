@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+const sectionsTestDoc = `# Templates
+
+Intro.
+
+## Creating a Template
+
+Use note.template.
+
+` + "```c\n# not a heading\n```" + `
+
+### Data Types
+
+Use 14.1 for floats.
+
+## Verifying a Template
+
+Check the response of note.template.
+`
+
+func TestSelectDocSections(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		keyword string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "by anchor includes subsections",
+			section: "creating-a-template",
+			want:    "## Creating a Template\n\nUse note.template.\n\n```c\n# not a heading\n```\n\n### Data Types\n\nUse 14.1 for floats.",
+		},
+		{
+			name:    "by heading",
+			section: "Verifying a Template",
+			want:    "## Verifying a Template\n\nCheck the response of note.template.",
+		},
+		{
+			name:    "by partial heading",
+			section: "data",
+			want:    "### Data Types\n\nUse 14.1 for floats.",
+		},
+		{
+			name:    "keyword returns each matching section's own text",
+			keyword: "NOTE.TEMPLATE",
+			want:    "## Creating a Template\n\nUse note.template.\n\n```c\n# not a heading\n```\n\n## Verifying a Template\n\nCheck the response of note.template.",
+		},
+		{
+			name:    "keyword within a section",
+			section: "creating-a-template",
+			keyword: "floats",
+			want:    "### Data Types\n\nUse 14.1 for floats.",
+		},
+		{name: "unknown section", section: "clearing", wantErr: true},
+		{name: "unknown keyword", keyword: "lorawan", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectDocSections(sectionsTestDoc, tt.section, tt.keyword)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectDocSections() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("selectDocSections() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocTableOfContents(t *testing.T) {
+	got := docTableOfContents("arduino", "templates", docOutline(sectionsTestDoc))
+	for _, want := range []string{
+		"- [Templates](#templates) (20 lines)",
+		"  - [Creating a Template](#creating-a-template) (12 lines)",
+		"    - [Data Types](#data-types) (4 lines)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("docTableOfContents() = %q, missing %q", got, want)
+		}
+	}
+	if strings.Contains(got, "not a heading") {
+		t.Errorf("docTableOfContents() included a code block comment: %q", got)
+	}
+}
@@ -26,12 +26,19 @@ type SessionData struct {
 	RequestCount int64             `json:"request_count"`
 	Metadata     map[string]string `json:"metadata,omitempty"`
 	RequestLog   []RequestLog      `json:"request_log,omitempty"`
+
+	// notecard is the session's simulated Notecard, created on first use
+	notecard *SimulatedNotecard
 }
 
 // SessionManager manages client sessions for the MCP server
 type SessionManager struct {
 	mu       sync.RWMutex
 	sessions map[string]*SessionData
+
+	// notecard is the simulated Notecard of requests without a session, e.g. over stdio, where
+	// the server has a single client
+	notecard *SimulatedNotecard
 }
 
 // NewSessionManager creates a new session manager
@@ -333,3 +340,24 @@ func (sm *SessionManager) GetSessionRequestHistory(sessionID string, limit int)
 	copy(result, session.RequestLog[logLen-limit:])
 	return result
 }
+
+// GetSimulatedNotecard returns the session's simulated Notecard, creating it on first use or replacing
+// it when reset is set. Requests without a session share one simulated Notecard; unknown sessions get
+// a new one each time.
+func (sm *SessionManager) GetSimulatedNotecard(sessionID string, reset bool) *SimulatedNotecard {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	notecard := &sm.notecard
+	if sessionID != "" && sessionID != "stateless" {
+		session, exists := sm.sessions[sessionID]
+		if !exists {
+			return NewSimulatedNotecard()
+		}
+		notecard = &session.notecard
+	}
+	if *notecard == nil || reset {
+		*notecard = NewSimulatedNotecard()
+	}
+	return *notecard
+}
@@ -0,0 +1,508 @@
+package lib
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Sources of simulated responses
+const (
+	simulatedFromState  = "state"
+	simulatedFromSample = "sample"
+	simulatedFromSchema = "schema"
+	simulatedFromError  = "error"
+	simulatedFromNone   = "none"
+)
+
+// schemaSourceSynthesized reports that a response schema came from the synthesized stand-ins bundled
+// into the binary rather than from the Notecard API schema
+const schemaSourceSynthesized = "synthesized"
+
+// synthesizedResponseSchemas are stand-ins for the response schemas of the Notecard API, written for
+// this server from the request schemas and the API reference. They are not part of the upstream
+// schema, so they are kept apart from the bundled snapshot and only used when the upstream response
+// schemas cannot be loaded.
+//
+//go:embed data/responses
+var synthesizedResponseSchemas embed.FS
+
+// simulatedDeviceUID is the DeviceUID reported by the simulated Notecard
+const simulatedDeviceUID = "dev:000000000000000"
+
+// SimulatedExchange is a request sent to the simulated Notecard and its response
+type SimulatedExchange struct {
+	Index        int                    `json:"index" jsonschema:"The position of the request in the sequence"`
+	Request      string                 `json:"request,omitempty" jsonschema:"The name of the request, e.g. 'note.add'"`
+	Valid        bool                   `json:"valid" jsonschema:"Whether the request is valid"`
+	Findings     []ValidationFinding    `json:"findings,omitempty" jsonschema:"Every problem found in the request"`
+	Response     map[string]interface{} `json:"response,omitempty" jsonschema:"The simulated response. Commands sent with 'cmd' have none"`
+	Source       string                 `json:"source" jsonschema:"Where the response comes from: 'state' (the simulated Notecard), 'sample' (a response sample of the schema), 'schema' (synthesized from the response schema), 'error' (the request is invalid) or 'none' (a command)"`
+	SchemaSource string                 `json:"schema_source,omitempty" jsonschema:"Where the response schema was loaded from. 'synthesized' schemas are bundled stand-ins, not part of the Notecard API schema"`
+}
+
+// SimulationOutput is the result of sending requests to the simulated Notecard
+type SimulationOutput struct {
+	Stateful  bool                `json:"stateful" jsonschema:"Whether the requests were applied to the session's simulated Notecard"`
+	Exchanges []SimulatedExchange `json:"exchanges,omitempty" jsonschema:"Each request and its simulated response, in order"`
+}
+
+// simulatedNote is a Note stored by the simulated Notecard
+type simulatedNote struct {
+	id      string
+	body    interface{}
+	payload string
+	time    int64
+}
+
+// SimulatedNotecard is an in-memory model of a Notecard's Notefiles, environment variables and Notehub
+// settings, used to answer note.add, note.get, file.changes, env.set, env.get, hub.set, hub.get and
+// hub.sync requests consistently across a session
+type SimulatedNotecard struct {
+	mu          sync.Mutex
+	notefiles   map[string][]simulatedNote
+	changes     map[string]int
+	env         map[string]string
+	envModified int64
+	hub         map[string]interface{}
+	nextNoteID  int
+}
+
+// NewSimulatedNotecard returns a simulated Notecard with no Notes, environment variables or settings
+func NewSimulatedNotecard() *SimulatedNotecard {
+	return &SimulatedNotecard{
+		notefiles: make(map[string][]simulatedNote),
+		changes:   make(map[string]int),
+		env:       make(map[string]string),
+		hub:       make(map[string]interface{}),
+	}
+}
+
+// SimulateNotecardRequests validates each request of a JSON request, JSON array or newline-delimited
+// JSON sequence and returns the response a Notecard would give. With a simulated Notecard, state-changing
+// requests are applied to it and answered from its state; other responses come from the response
+// samples and definitions of the schema.
+func SimulateNotecardRequests(requests string, notecard *SimulatedNotecard, schemaURL string) (*SimulationOutput, error) {
+	items, err := splitNotecardRequests(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+	if err := initSchema(schemaURL); err != nil {
+		return nil, fmt.Errorf("failed to initialize schema: %v", err)
+	}
+	release := schemaReleaseForURL(schemaURL)
+
+	output := &SimulationOutput{Stateful: notecard != nil}
+	for i, item := range items {
+		itemResult := BatchItemResult{Index: i, Line: item.line}
+		reqMap := validateRawRequest(&itemResult, item.data, schemaURL)
+		exchange := SimulatedExchange{Index: i, Request: itemResult.Request, Valid: itemResult.Valid, Findings: itemResult.Findings}
+		if !itemResult.Valid {
+			exchange.Response, exchange.Source = map[string]interface{}{"err": itemResult.Error}, simulatedFromError
+			output.Exchanges = append(output.Exchanges, exchange)
+			continue
+		}
+
+		response, handled := notecard.apply(itemResult.Request, reqMap)
+		if handled {
+			exchange.Source = simulatedFromState
+		} else {
+			response, exchange.Source, exchange.SchemaSource = release.simulateResponse(itemResult.Request)
+		}
+
+		// Commands are processed without a response
+		if _, isRequest := reqMap["req"]; !isRequest {
+			response, exchange.Source = nil, simulatedFromNone
+		}
+		exchange.Response = response
+		output.Exchanges = append(output.Exchanges, exchange)
+	}
+	return output, nil
+}
+
+// simulateResponse returns a response for an API from the first sample of its response schema, or
+// synthesized from the response properties if it has no samples, with where the schema was loaded from
+func (r *schemaRelease) simulateResponse(apiName string) (map[string]interface{}, string, string) {
+	schema, schemaSource, err := r.loadResponseSchema(apiName)
+	if err != nil {
+		return map[string]interface{}{}, simulatedFromSchema, ""
+	}
+
+	if samples, ok := schema["samples"].([]interface{}); ok && len(samples) > 0 {
+		if sample, ok := samples[0].(map[string]interface{}); ok {
+			if data, ok := sample["json"].(string); ok {
+				var response map[string]interface{}
+				if err := json.Unmarshal([]byte(data), &response); err == nil && response != nil {
+					return response, simulatedFromSample, schemaSource
+				}
+			}
+		}
+	}
+
+	response := map[string]interface{}{}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, value := range properties {
+		property, _ := value.(map[string]interface{})
+		response[name] = exampleValue(property)
+	}
+	return response, simulatedFromSchema, schemaSource
+}
+
+// loadResponseSchema loads the response schema of an API, found next to its request schema, and
+// returns where it was loaded from. When the schema comes from the bundled snapshot, or the upstream
+// response schema cannot be fetched, the synthesized stand-in is used.
+func (r *schemaRelease) loadResponseSchema(apiName string) (map[string]interface{}, string, error) {
+	data, err := os.ReadFile(r.requestSchemaPath(apiName))
+	if err != nil {
+		return nil, "", fmt.Errorf("request schema of %s not found: %v", apiName, err)
+	}
+	var requestSchema map[string]interface{}
+	if err := json.Unmarshal(data, &requestSchema); err != nil {
+		return nil, "", fmt.Errorf("invalid request schema of %s: %v", apiName, err)
+	}
+	id, _ := requestSchema["$id"].(string)
+	if !strings.HasSuffix(id, ".req.notecard.api.json") {
+		return nil, "", fmt.Errorf("request schema of %s has no $id", apiName)
+	}
+	url := strings.TrimSuffix(id, ".req.notecard.api.json") + ".rsp.notecard.api.json"
+
+	var reader io.Reader
+	schemaSource := GetSchemaSource(r.url)
+	if r.diskDir == "" && (offlineMode || schemaSource == schemaSourceSnapshot) {
+		reader, err = loadSynthesizedResponseSchema(url)
+		schemaSource = schemaSourceSynthesized
+	} else if reader, err = r.loadOrFetchSchema(url); err != nil && r.diskDir == "" {
+		log.Warn().Str("url", url).Err(err).Msg("Failed to fetch response schema, falling back to the synthesized response schema")
+		reader, err = loadSynthesizedResponseSchema(url)
+		schemaSource = schemaSourceSynthesized
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	var schema map[string]interface{}
+	if err := json.NewDecoder(reader).Decode(&schema); err != nil {
+		return nil, "", fmt.Errorf("invalid response schema of %s: %v", apiName, err)
+	}
+	return schema, schemaSource, nil
+}
+
+// loadSynthesizedResponseSchema loads the synthesized stand-in for a response schema. It is not cached,
+// so that it is never mistaken for the upstream schema.
+func loadSynthesizedResponseSchema(url string) (io.Reader, error) {
+	filename := filepath.Base(url)
+	data, err := synthesizedResponseSchemas.ReadFile("data/responses/" + filename)
+	if err != nil {
+		return nil, fmt.Errorf("response schema %s not found: %v", filename, err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// exampleValue returns a value for a schema property: its default, first enum value or example,
+// or the zero value of its type
+func exampleValue(property map[string]interface{}) interface{} {
+	if value, ok := property["default"]; ok {
+		return value
+	}
+	if values, ok := property["enum"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+	if values, ok := property["examples"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+	switch property["type"] {
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "object":
+		return map[string]interface{}{}
+	case "array":
+		return []interface{}{}
+	}
+	return ""
+}
+
+// apply applies a request to the simulated Notecard and returns its response, or false if the
+// request is not modeled. A nil simulated Notecard models no requests.
+func (n *SimulatedNotecard) apply(apiName string, req map[string]interface{}) (map[string]interface{}, bool) {
+	if n == nil {
+		return nil, false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch apiName {
+	case "note.add":
+		return n.noteAdd(req), true
+	case "note.get":
+		return n.noteGet(req), true
+	case "file.changes":
+		return n.fileChanges(req), true
+	case "env.set":
+		return n.envSet(req), true
+	case "env.get":
+		return n.envGet(req), true
+	case "hub.set":
+		for key, value := range req {
+			if key != "req" && key != "cmd" && key != "id" {
+				n.hub[key] = value
+			}
+		}
+		return map[string]interface{}{}, true
+	case "hub.get":
+		response := map[string]interface{}{"device": simulatedDeviceUID, "host": "a.notefile.net", "mode": "periodic"}
+		for key, value := range n.hub {
+			response[key] = value
+		}
+		return response, true
+	case "hub.sync":
+		// Syncs complete immediately, sending the outbound queues and database changes
+		for file := range n.notefiles {
+			if isOutboundQueue(file) {
+				delete(n.notefiles, file)
+			}
+		}
+		n.changes = make(map[string]int)
+		return map[string]interface{}{}, true
+	}
+	return nil, false
+}
+
+// noteAdd adds a Note to a Notefile
+func (n *SimulatedNotecard) noteAdd(req map[string]interface{}) map[string]interface{} {
+	file := stringOr(req["file"], "data.qo")
+	noteID, _ := req["note"].(string)
+	if isDatabase(file) && noteID == "" {
+		return simulatedError("note ID is required for database Notefiles {note-noexist}")
+	}
+	if !isDatabase(file) && noteID != "" {
+		return simulatedError("note ID cannot be specified for queue Notefiles")
+	}
+	if isDatabase(file) && n.findNote(file, noteID) >= 0 {
+		return simulatedError(fmt.Sprintf("note %s already exists in %s {note-exists}", noteID, file))
+	}
+	if noteID == "" {
+		n.nextNoteID++
+		noteID = strconv.Itoa(n.nextNoteID)
+	}
+
+	payload, _ := req["payload"].(string)
+	n.notefiles[file] = append(n.notefiles[file], simulatedNote{id: noteID, body: req["body"], payload: payload, time: time.Now().Unix()})
+	if syncsToNotehub(file) {
+		n.changes[file]++
+	}
+	return map[string]interface{}{"total": len(n.notefiles[file])}
+}
+
+// noteGet returns a Note from a database, or the oldest Note of an inbound queue
+func (n *SimulatedNotecard) noteGet(req map[string]interface{}) map[string]interface{} {
+	file := stringOr(req["file"], "data.qi")
+	if isOutboundQueue(file) {
+		return simulatedError(fmt.Sprintf("cannot get a Note from the outbound queue %s", file))
+	}
+
+	index := 0
+	if isDatabase(file) {
+		noteID, _ := req["note"].(string)
+		if index = n.findNote(file, noteID); index < 0 {
+			return simulatedError(fmt.Sprintf("note %s not found in %s {note-noexist}", noteID, file))
+		}
+	} else if len(n.notefiles[file]) == 0 {
+		return simulatedError(fmt.Sprintf("no notes available in queue %s {note-noexist}", file))
+	}
+
+	note := n.notefiles[file][index]
+	response := map[string]interface{}{"time": note.time}
+	if note.body != nil {
+		response["body"] = note.body
+	}
+	if note.payload != "" {
+		response["payload"] = note.payload
+	}
+	if isDatabase(file) {
+		response["note"] = note.id
+	}
+	if remove, _ := req["delete"].(bool); remove {
+		n.notefiles[file] = append(n.notefiles[file][:index], n.notefiles[file][index+1:]...)
+	}
+	return response
+}
+
+// fileChanges reports the Notes and pending changes of the requested Notefiles, or of all of them
+func (n *SimulatedNotecard) fileChanges(req map[string]interface{}) map[string]interface{} {
+	var files []string
+	if requested, ok := req["files"].([]interface{}); ok {
+		for _, file := range requested {
+			if name, ok := file.(string); ok {
+				files = append(files, name)
+			}
+		}
+	} else {
+		for file := range n.notefiles {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+	}
+
+	total, changes := 0, 0
+	info := map[string]interface{}{}
+	for _, file := range files {
+		fileInfo := map[string]interface{}{"total": len(n.notefiles[file])}
+		if n.changes[file] > 0 {
+			fileInfo["changes"] = n.changes[file]
+		}
+		info[file] = fileInfo
+		total += len(n.notefiles[file])
+		changes += n.changes[file]
+	}
+	response := map[string]interface{}{"total": total, "info": info}
+	if changes > 0 {
+		response["changes"] = changes
+	}
+	return response
+}
+
+// envSet sets or, with empty text, deletes an environment variable
+func (n *SimulatedNotecard) envSet(req map[string]interface{}) map[string]interface{} {
+	name, _ := req["name"].(string)
+	if name == "" {
+		return simulatedError("environment variable name is required")
+	}
+	if text, _ := req["text"].(string); text != "" {
+		n.env[name] = text
+	} else {
+		delete(n.env, name)
+	}
+	n.envModified = time.Now().Unix()
+	return map[string]interface{}{}
+}
+
+// envGet returns an environment variable, the requested variables or all of them
+func (n *SimulatedNotecard) envGet(req map[string]interface{}) map[string]interface{} {
+	response := map[string]interface{}{}
+	if n.envModified > 0 {
+		response["time"] = n.envModified
+	}
+	if name, ok := req["name"].(string); ok {
+		if text, ok := n.env[name]; ok {
+			response["text"] = text
+		}
+		return response
+	}
+
+	body := map[string]interface{}{}
+	names, filtered := req["names"].([]interface{})
+	for name, text := range n.env {
+		if !filtered || containsValue(names, name) {
+			body[name] = text
+		}
+	}
+	response["body"] = body
+	return response
+}
+
+// findNote returns the index of a Note in a Notefile, or -1
+func (n *SimulatedNotecard) findNote(file string, noteID string) int {
+	for i, note := range n.notefiles[file] {
+		if note.id == noteID {
+			return i
+		}
+	}
+	return -1
+}
+
+// isDatabase reports whether a Notefile is a database (.db, .dbs or .dbx) rather than a queue
+func isDatabase(file string) bool {
+	return strings.HasSuffix(file, ".db") || strings.HasSuffix(file, ".dbs") || strings.HasSuffix(file, ".dbx")
+}
+
+// isOutboundQueue reports whether a Notefile is an outbound queue (.qo or .qos)
+func isOutboundQueue(file string) bool {
+	return strings.HasSuffix(file, ".qo") || strings.HasSuffix(file, ".qos")
+}
+
+// syncsToNotehub reports whether changes to a Notefile are synced to Notehub. Local databases
+// (.dbx) and inbound queues are not.
+func syncsToNotehub(file string) bool {
+	return isOutboundQueue(file) || strings.HasSuffix(file, ".db") || strings.HasSuffix(file, ".dbs")
+}
+
+// simulatedError returns a Notecard error response
+func simulatedError(message string) map[string]interface{} {
+	return map[string]interface{}{"err": message}
+}
+
+// stringOr returns a JSON value as a string, or the fallback if it is not a non-empty string
+func stringOr(value interface{}, fallback string) string {
+	if text, ok := value.(string); ok && text != "" {
+		return text
+	}
+	return fallback
+}
+
+// containsValue reports whether a JSON array contains a string
+func containsValue(values []interface{}, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSimulation renders the exchanges as a Notecard request and response trace
+func formatSimulation(output *SimulationOutput, requests string) string {
+	var b strings.Builder
+	mode := "stateless"
+	if output.Stateful {
+		mode = "stateful, applied to this session's simulated Notecard"
+	}
+	fmt.Fprintf(&b, "Simulated %d request(s) (%s). Responses are plausible examples, not real device output.\n", len(output.Exchanges), mode)
+
+	items, _ := splitNotecardRequests(requests)
+	for i, exchange := range output.Exchanges {
+		b.WriteString("\n> ")
+		if i < len(items) {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, items[i].data); err != nil {
+				compact.Write(items[i].data)
+			}
+			b.WriteString(compact.String())
+		}
+		b.WriteString("\n")
+		if exchange.Response == nil {
+			b.WriteString("  (command, no response)\n")
+			continue
+		}
+		response, err := json.Marshal(exchange.Response)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "< %s\n", response)
+		for _, finding := range exchange.Findings {
+			fmt.Fprintf(&b, "  %s: %s\n", finding.Pointer, finding.Message)
+		}
+	}
+	for _, exchange := range output.Exchanges {
+		if exchange.SchemaSource == schemaSourceSynthesized {
+			b.WriteString("\nResponses marked with a synthesized schema_source come from response schemas bundled with this server as stand-ins; they are not part of the Notecard API schema.\n")
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestSimulateNotecardRequestsStateful(t *testing.T) {
+	notecard := NewSimulatedNotecard()
+	requests := `{"req":"note.add","file":"readings.qo","body":{"temp":21.5}}
+{"req":"note.add","file":"config.db","note":"settings","body":{"rate":5}}
+{"req":"note.get","file":"config.db","note":"settings"}
+{"req":"file.changes"}
+{"req":"env.set","name":"rate","text":"10"}
+{"req":"env.get","name":"rate"}
+{"cmd":"hub.sync"}
+{"req":"file.changes","files":["readings.qo"]}`
+	output, err := SimulateNotecardRequests(requests, notecard, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !output.Stateful || len(output.Exchanges) != 8 {
+		t.Fatalf("SimulateNotecardRequests() = %+v", output)
+	}
+	for _, exchange := range output.Exchanges {
+		if !exchange.Valid {
+			t.Fatalf("SimulateNotecardRequests() %s is invalid: %+v", exchange.Request, exchange.Findings)
+		}
+	}
+
+	get := output.Exchanges[2]
+	body, _ := get.Response["body"].(map[string]interface{})
+	if get.Source != simulatedFromState || body["rate"] != float64(5) || get.Response["note"] != "settings" {
+		t.Errorf("note.get response = %+v", get.Response)
+	}
+	if changes := output.Exchanges[3].Response; changes["total"] != 2 || changes["changes"] != 2 {
+		t.Errorf("file.changes response = %+v", changes)
+	}
+	if env := output.Exchanges[5].Response; env["text"] != "10" {
+		t.Errorf("env.get response = %+v", env)
+	}
+	if sync := output.Exchanges[6]; sync.Response != nil || sync.Source != simulatedFromNone {
+		t.Errorf("hub.sync command = %+v", sync)
+	}
+	if changes := output.Exchanges[7].Response; changes["total"] != 0 {
+		t.Errorf("file.changes after hub.sync = %+v", changes)
+	}
+
+	// State is kept across calls
+	output, err = SimulateNotecardRequests(`{"req":"note.add","file":"config.db","note":"settings","body":{}}`, notecard, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response := output.Exchanges[0].Response; response["err"] == nil {
+		t.Errorf("note.add of an existing Note = %+v", response)
+	}
+}
+
+func TestSimulateNotecardRequestsStateless(t *testing.T) {
+	output, err := SimulateNotecardRequests(`[{"req":"card.version"},{"req":"note.get","file":"data.qi"},{"req":"hub.set","mode":"sometimes"}]`, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Stateful || len(output.Exchanges) != 3 {
+		t.Fatalf("SimulateNotecardRequests() = %+v", output)
+	}
+
+	version := output.Exchanges[0]
+	if version.Source != simulatedFromSample || version.Response["version"] == nil {
+		t.Errorf("card.version exchange = %+v", version)
+	}
+	if get := output.Exchanges[1]; get.Source == simulatedFromState || get.Source == simulatedFromError {
+		t.Errorf("stateless note.get exchange = %+v", get)
+	}
+	invalid := output.Exchanges[2]
+	if invalid.Valid || invalid.Source != simulatedFromError || invalid.Response["err"] == nil || len(invalid.Findings) == 0 {
+		t.Errorf("invalid hub.set exchange = %+v", invalid)
+	}
+}
+
+func TestExampleValue(t *testing.T) {
+	tests := []struct {
+		property map[string]interface{}
+		want     interface{}
+	}{
+		{map[string]interface{}{"type": "string", "default": "periodic"}, "periodic"},
+		{map[string]interface{}{"type": "string", "enum": []interface{}{"usb", "lipo"}}, "usb"},
+		{map[string]interface{}{"type": "integer"}, 0},
+		{map[string]interface{}{"type": "boolean"}, false},
+		{map[string]interface{}{"type": "string"}, ""},
+	}
+	for _, tt := range tests {
+		if got := exampleValue(tt.property); got != tt.want {
+			t.Errorf("exampleValue(%v) = %v, want %v", tt.property, got, tt.want)
+		}
+	}
+}
+
+func TestGetSimulatedNotecard(t *testing.T) {
+	sm := &SessionManager{sessions: make(map[string]*SessionData)}
+	sm.sessions["abc"] = &SessionData{ID: "abc"}
+
+	notecard := sm.GetSimulatedNotecard("abc", false)
+	if sm.GetSimulatedNotecard("abc", false) != notecard {
+		t.Error("GetSimulatedNotecard() did not keep the session's simulated Notecard")
+	}
+	if sm.GetSimulatedNotecard("abc", true) == notecard {
+		t.Error("GetSimulatedNotecard() did not reset the session's simulated Notecard")
+	}
+	if sm.GetSimulatedNotecard("", false) != sm.GetSimulatedNotecard("", false) {
+		t.Error("GetSimulatedNotecard() did not share the simulated Notecard of requests without a session")
+	}
+	if sm.GetSimulatedNotecard("unknown", false) == sm.GetSimulatedNotecard("unknown", false) {
+		t.Error("GetSimulatedNotecard() kept a simulated Notecard for an unknown session")
+	}
+}
+
+func TestHandleNotecardSimulateToolReset(t *testing.T) {
+	previous := globalSessionManager
+	globalSessionManager = &SessionManager{sessions: make(map[string]*SessionData)}
+	defer func() { globalSessionManager = previous }()
+
+	add := SimulateArgs{Requests: `{"req":"note.add","file":"data.qo","body":{"temp":21}}`, Stateful: true}
+	if result, _, _ := HandleNotecardSimulateTool(context.Background(), nil, add); result.IsError {
+		t.Fatalf("HandleNotecardSimulateTool() = %+v", result)
+	}
+	if len(GetSessionManager().GetSimulatedNotecard("", false).notefiles) != 1 {
+		t.Fatal("HandleNotecardSimulateTool() did not add the Note to the simulated Notecard")
+	}
+
+	// reset without stateful still starts the simulated Notecard afresh
+	reset := SimulateArgs{Requests: `{"req":"card.version"}`, Reset: true}
+	if result, output, _ := HandleNotecardSimulateTool(context.Background(), nil, reset); result.IsError || output.Stateful {
+		t.Fatalf("HandleNotecardSimulateTool() = %+v", result)
+	}
+	if len(GetSessionManager().GetSimulatedNotecard("", false).notefiles) != 0 {
+		t.Error("HandleNotecardSimulateTool() ignored reset without stateful")
+	}
+}
+
+func TestSynthesizedResponseSchemas(t *testing.T) {
+	snapshot, err := fs.Glob(schemaSnapshot, "schema/*.rsp.notecard.api.json")
+	if err != nil || len(snapshot) != 0 {
+		t.Errorf("bundled snapshot holds response schemas %v, want them kept apart from the upstream snapshot", snapshot)
+	}
+
+	synthesized, err := fs.Glob(synthesizedResponseSchemas, "data/responses/*.rsp.notecard.api.json")
+	if err != nil || len(synthesized) == 0 {
+		t.Fatalf("no synthesized response schemas found: %v", err)
+	}
+	for _, path := range synthesized {
+		data, err := synthesizedResponseSchemas.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		comment, _ := schema["$comment"].(string)
+		if !strings.HasPrefix(comment, "Synthesized") || schema["version"] != nil {
+			t.Errorf("%s is not labelled as synthesized, or claims an upstream schema version", path)
+		}
+		request := "schema/" + strings.TrimSuffix(strings.TrimPrefix(path, "data/responses/"), ".rsp.notecard.api.json") + ".req.notecard.api.json"
+		if _, err := fs.Stat(schemaSnapshot, request); err != nil {
+			t.Errorf("%s has no request schema in the bundled snapshot", path)
+		}
+	}
+}
@@ -0,0 +1,894 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Origins of requests extracted from source code
+const (
+	sourceOriginNoteC       = "note-c"
+	sourceOriginArduino     = "note-arduino"
+	sourceOriginPython      = "python"
+	sourceOriginJSONLiteral = "json"
+)
+
+// ExtractedRequest is a Notecard request reconstructed statically from source code
+type ExtractedRequest struct {
+	Line    int                    `json:"line"`
+	Origin  string                 `json:"origin"`
+	Request map[string]interface{} `json:"request"`
+	// Dynamic lists JSON pointers of properties whose values are not known statically
+	Dynamic []string `json:"dynamic,omitempty"`
+	// Error is set when a request literal was found but could not be parsed
+	Error string `json:"error,omitempty"`
+}
+
+// SourceRequestResult holds the validation outcome of a request extracted from source code
+type SourceRequestResult struct {
+	BatchItemResult
+	Origin        string                 `json:"origin"`
+	Reconstructed map[string]interface{} `json:"reconstructed"`
+	Dynamic       []string               `json:"dynamic,omitempty"`
+}
+
+// SourceValidationResult holds the per-request results and summary of a source validation
+type SourceValidationResult struct {
+	Total   int                   `json:"total"`
+	Valid   int                   `json:"valid"`
+	Invalid int                   `json:"invalid"`
+	Results []SourceRequestResult `json:"results,omitempty"`
+}
+
+// dynamicValue marks a value that cannot be determined statically. The placeholder is used in
+// its place so that the property type can still be checked where the API call implies it.
+type dynamicValue struct {
+	placeholder interface{}
+}
+
+// sourceArray is a JSON array under construction, referenced by pointer so later additions are kept
+type sourceArray struct {
+	items []interface{}
+}
+
+// ValidateSourceRequests extracts Notecard requests from firmware source code and validates each one.
+// Supported languages are "c", "cpp", "arduino", "python" and "json"; an empty language tries all extractors.
+func ValidateSourceRequests(source string, language string, schemaURL string) (*SourceValidationResult, error) {
+	extracted, err := ExtractSourceRequests(source, language)
+	if err != nil {
+		return nil, err
+	}
+	if len(extracted) == 0 {
+		return nil, fmt.Errorf("no Notecard requests found in the source")
+	}
+
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+	if err := initSchema(schemaURL); err != nil {
+		return nil, fmt.Errorf("failed to initialize schema: %v", err)
+	}
+
+	result := &SourceValidationResult{
+		Total:   len(extracted),
+		Results: make([]SourceRequestResult, 0, len(extracted)),
+	}
+	for i, req := range extracted {
+		itemResult := SourceRequestResult{
+			BatchItemResult: BatchItemResult{
+				Index: i,
+				Line:  req.Line,
+			},
+			Origin:        req.Origin,
+			Reconstructed: req.Request,
+			Dynamic:       req.Dynamic,
+		}
+		if req.Error != "" {
+			itemResult.Error = req.Error
+		} else {
+			validateBatchItem(&itemResult.BatchItemResult, req.Request, schemaURL)
+			ignoreDynamicFindings(&itemResult.BatchItemResult, req.Dynamic)
+		}
+
+		if itemResult.Valid {
+			result.Valid++
+		} else {
+			result.Invalid++
+		}
+		result.Results = append(result.Results, itemResult)
+	}
+
+	return result, nil
+}
+
+// ignoreDynamicFindings drops findings about values that are not known statically. Unknown property
+// names are always kept, as are type mismatches where the API call implies the value's type.
+func ignoreDynamicFindings(itemResult *BatchItemResult, dynamic []string) {
+	if itemResult.Valid || len(itemResult.Findings) == 0 || len(dynamic) == 0 {
+		return
+	}
+
+	isDynamic := make(map[string]bool, len(dynamic))
+	for _, pointer := range dynamic {
+		isDynamic[pointer] = true
+	}
+
+	var kept []ValidationFinding
+	for _, finding := range itemResult.Findings {
+		if isDynamic[finding.Pointer] && finding.Keyword != "additionalProperties" && (finding.Keyword != "type" || finding.Value == nil) {
+			continue
+		}
+		kept = append(kept, finding)
+	}
+
+	itemResult.Findings = kept
+	if len(kept) == 0 {
+		itemResult.Valid = true
+		itemResult.Error = ""
+	} else {
+		itemResult.Error = (&RequestValidationError{ReqType: itemResult.Request, Findings: kept}).Error()
+	}
+}
+
+// ExtractSourceRequests statically reconstructs Notecard requests from source code
+func ExtractSourceRequests(source string, language string) ([]ExtractedRequest, error) {
+	var extracted []ExtractedRequest
+
+	switch strings.ToLower(language) {
+	case "c", "cpp", "c++", "arduino":
+		extracted = append(extracted, extractNoteCRequests(source)...)
+		extracted = append(extracted, extractStringLiteralRequests(source)...)
+	case "python":
+		extracted = append(extracted, extractObjectLiteralRequests(source, sourceOriginPython)...)
+		extracted = append(extracted, extractStringLiteralRequests(source)...)
+	case "json":
+		extracted = append(extracted, extractObjectLiteralRequests(source, sourceOriginJSONLiteral)...)
+	case "":
+		extracted = append(extracted, extractNoteCRequests(source)...)
+		extracted = append(extracted, extractStringLiteralRequests(source)...)
+		origin := sourceOriginJSONLiteral
+		if strings.Contains(source, ".Transaction(") || strings.Contains(source, "import notecard") {
+			origin = sourceOriginPython
+		}
+		extracted = append(extracted, extractObjectLiteralRequests(source, origin)...)
+	default:
+		return nil, fmt.Errorf("unsupported language '%s'. Valid values are: c, cpp, arduino, python, json", language)
+	}
+
+	sort.SliceStable(extracted, func(i, j int) bool {
+		return extracted[i].Line < extracted[j].Line
+	})
+	return extracted, nil
+}
+
+// noteCCallPattern matches the note-c / note-arduino calls used to build requests
+var noteCCallPattern = regexp.MustCompile(`\b(?:[A-Za-z_]\w*\s*(?:\.|->)\s*)?(NoteNewRequest|NoteNewCommand|newRequest|newCommand|JCreateObject|JCreateArray|JAdd\w*ToObject|JAddItemToArray)\s*\(`)
+
+// assignmentPattern matches an assignment target immediately preceding a call
+var assignmentPattern = regexp.MustCompile(`([A-Za-z_]\w*)\s*=\s*$`)
+
+// extractNoteCRequests reconstructs requests built with note-c (NoteNewRequest/JAdd*ToObject)
+// or note-arduino (notecard.newRequest) call chains
+func extractNoteCRequests(source string) []ExtractedRequest {
+	code := stripCComments(source)
+
+	type root struct {
+		line   int
+		origin string
+		object map[string]interface{}
+	}
+	var roots []root
+	vars := make(map[string]interface{})
+
+	for _, match := range noteCCallPattern.FindAllStringSubmatchIndex(code, -1) {
+		name := code[match[2]:match[3]]
+		openParen := match[1] - 1
+		args, ok := splitCallArgs(code, openParen)
+		if !ok {
+			continue
+		}
+
+		target := ""
+		if assignment := assignmentPattern.FindStringSubmatch(code[max(0, match[0]-64):match[0]]); assignment != nil {
+			target = assignment[1]
+		}
+
+		switch name {
+		case "NoteNewRequest", "NoteNewCommand", "newRequest", "newCommand":
+			if len(args) != 1 {
+				continue
+			}
+			reqType, ok := parseCStringLiteral(args[0])
+			if !ok {
+				continue
+			}
+			key := "req"
+			if strings.HasSuffix(name, "Command") {
+				key = "cmd"
+			}
+			origin := sourceOriginNoteC
+			if strings.HasPrefix(name, "new") {
+				origin = sourceOriginArduino
+			}
+			object := map[string]interface{}{key: reqType}
+			roots = append(roots, root{line: lineAt(code, match[0]), origin: origin, object: object})
+			if target != "" {
+				vars[target] = object
+			}
+		case "JCreateObject":
+			if target != "" {
+				vars[target] = map[string]interface{}{}
+			}
+		case "JCreateArray":
+			if target != "" {
+				vars[target] = &sourceArray{}
+			}
+		case "JAddItemToArray":
+			if len(args) != 2 {
+				continue
+			}
+			if array, ok := vars[strings.TrimSpace(args[0])].(*sourceArray); ok {
+				array.items = append(array.items, cItemValue(args[1], vars))
+			}
+		default:
+			if len(args) < 2 {
+				continue
+			}
+			object, ok := vars[strings.TrimSpace(args[0])].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			property, ok := parseCStringLiteral(args[1])
+			if !ok {
+				continue
+			}
+			value, ok := cObjectValue(name, args[2:], vars)
+			if !ok {
+				continue
+			}
+			object[property] = value
+			if target != "" && (name == "JAddObjectToObject" || name == "JAddArrayToObject") {
+				vars[target] = value
+			}
+		}
+	}
+
+	extracted := make([]ExtractedRequest, 0, len(roots))
+	for _, r := range roots {
+		var dynamic []string
+		request, _ := materializeSourceValue(r.object, "", &dynamic).(map[string]interface{})
+		extracted = append(extracted, ExtractedRequest{
+			Line:    r.line,
+			Origin:  r.origin,
+			Request: request,
+			Dynamic: dynamic,
+		})
+	}
+	return extracted
+}
+
+// cObjectValue returns the value added to an object by a JAdd*ToObject call
+func cObjectValue(name string, args []string, vars map[string]interface{}) (interface{}, bool) {
+	switch name {
+	case "JAddStringToObject":
+		if len(args) != 1 {
+			return nil, false
+		}
+		if s, ok := parseCStringLiteral(args[0]); ok {
+			return s, true
+		}
+		return dynamicValue{placeholder: ""}, true
+	case "JAddNumberToObject", "JAddIntToObject":
+		if len(args) != 1 {
+			return nil, false
+		}
+		if n, ok := parseCNumberLiteral(args[0]); ok {
+			return n, true
+		}
+		return dynamicValue{placeholder: float64(0)}, true
+	case "JAddBoolToObject":
+		if len(args) != 1 {
+			return nil, false
+		}
+		switch strings.TrimSpace(args[0]) {
+		case "true", "TRUE", "1":
+			return true, true
+		case "false", "FALSE", "0":
+			return false, true
+		}
+		return dynamicValue{placeholder: false}, true
+	case "JAddTrueToObject":
+		return true, true
+	case "JAddFalseToObject":
+		return false, true
+	case "JAddNullToObject":
+		return nil, true
+	case "JAddObjectToObject":
+		return map[string]interface{}{}, true
+	case "JAddArrayToObject":
+		return &sourceArray{}, true
+	case "JAddItemToObject", "JAddItemReferenceToObject":
+		if len(args) != 1 {
+			return nil, false
+		}
+		return cItemValue(args[0], vars), true
+	}
+	return nil, false
+}
+
+// cCreatePattern matches an inline JCreate* call
+var cCreatePattern = regexp.MustCompile(`^JCreate(\w+)\s*\((.*)\)$`)
+
+// cItemValue resolves a J item argument, either a variable or an inline JCreate* call
+func cItemValue(arg string, vars map[string]interface{}) interface{} {
+	arg = strings.TrimSpace(arg)
+	if value, ok := vars[arg]; ok {
+		return value
+	}
+
+	if match := cCreatePattern.FindStringSubmatch(arg); match != nil {
+		inner := strings.TrimSpace(match[2])
+		switch match[1] {
+		case "String":
+			if s, ok := parseCStringLiteral(inner); ok {
+				return s
+			}
+			return dynamicValue{placeholder: ""}
+		case "Number":
+			if n, ok := parseCNumberLiteral(inner); ok {
+				return n
+			}
+			return dynamicValue{placeholder: float64(0)}
+		case "Bool":
+			return inner == "true" || inner == "TRUE" || inner == "1"
+		case "True":
+			return true
+		case "False":
+			return false
+		case "Object":
+			return map[string]interface{}{}
+		case "Array":
+			return &sourceArray{}
+		case "Null":
+			return nil
+		}
+	}
+
+	return dynamicValue{}
+}
+
+// materializeSourceValue converts a value under construction into plain JSON values, recording the
+// JSON pointers of dynamic values
+func materializeSourceValue(value interface{}, pointer string, dynamic *[]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			escaped := strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+			object[key] = materializeSourceValue(item, pointer+"/"+escaped, dynamic)
+		}
+		return object
+	case *sourceArray:
+		array := make([]interface{}, 0, len(v.items))
+		for i, item := range v.items {
+			array = append(array, materializeSourceValue(item, fmt.Sprintf("%s/%d", pointer, i), dynamic))
+		}
+		return array
+	case []interface{}:
+		array := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			array = append(array, materializeSourceValue(item, fmt.Sprintf("%s/%d", pointer, i), dynamic))
+		}
+		return array
+	case dynamicValue:
+		*dynamic = append(*dynamic, pointer)
+		return v.placeholder
+	default:
+		return v
+	}
+}
+
+// splitCallArgs splits the arguments of a call whose opening parenthesis is at openParen,
+// respecting nested brackets and string/character literals
+func splitCallArgs(code string, openParen int) ([]string, bool) {
+	var args []string
+	depth := 0
+	start := openParen + 1
+	for i := openParen; i < len(code); i++ {
+		switch c := code[i]; c {
+		case '"', '\'':
+			end := skipQuoted(code, i)
+			if end < 0 {
+				return nil, false
+			}
+			i = end
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				if arg := strings.TrimSpace(code[start:i]); arg != "" || len(args) > 0 {
+					args = append(args, arg)
+				}
+				return args, true
+			}
+		case ',':
+			if depth == 1 {
+				args = append(args, strings.TrimSpace(code[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return nil, false
+}
+
+// skipQuoted returns the index of the closing quote of the literal starting at start, or -1
+func skipQuoted(code string, start int) int {
+	quote := code[start]
+	for i := start + 1; i < len(code); i++ {
+		switch code[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+// cStringLiteralPattern matches a single C string literal
+var cStringLiteralPattern = regexp.MustCompile(`"(?:[^"\\\n]|\\.)*"`)
+
+// parseCStringLiteral parses a C string literal, including adjacent literal concatenation
+func parseCStringLiteral(arg string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" || arg[0] != '"' {
+		return "", false
+	}
+
+	var sb strings.Builder
+	rest := arg
+	for rest != "" {
+		literal := cStringLiteralPattern.FindString(rest)
+		if literal == "" || !strings.HasPrefix(rest, literal) {
+			return "", false
+		}
+		unquoted, err := unquoteSourceString(literal)
+		if err != nil {
+			return "", false
+		}
+		sb.WriteString(unquoted)
+		rest = strings.TrimSpace(rest[len(literal):])
+	}
+	return sb.String(), true
+}
+
+// parseCNumberLiteral parses a C numeric literal, ignoring integer and float suffixes
+func parseCNumberLiteral(arg string) (float64, bool) {
+	arg = strings.TrimRight(strings.TrimSpace(arg), "fFlLuU")
+	if strings.HasPrefix(arg, "0x") || strings.HasPrefix(arg, "0X") {
+		n, err := strconv.ParseInt(arg[2:], 16, 64)
+		return float64(n), err == nil
+	}
+	n, err := strconv.ParseFloat(arg, 64)
+	return n, err == nil
+}
+
+// stripCComments blanks out C/C++ comments, preserving line breaks and string literals
+func stripCComments(source string) string {
+	out := []byte(source)
+	for i := 0; i < len(out); i++ {
+		switch {
+		case out[i] == '"' || out[i] == '\'':
+			if end := skipQuoted(source, i); end > 0 {
+				i = end
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := strings.Index(source[i+2:], "*/")
+			stop := len(out)
+			if end >= 0 {
+				stop = i + 2 + end + 2
+			}
+			for ; i < stop; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			i--
+		}
+	}
+	return string(out)
+}
+
+// extractStringLiteralRequests finds requests written as JSON inside string literals
+// (e.g. NoteRequestResponseJSON("{\"req\":\"card.version\"}"))
+func extractStringLiteralRequests(source string) []ExtractedRequest {
+	var extracted []ExtractedRequest
+	for i := 0; i < len(source); i++ {
+		if source[i] != '"' && source[i] != '\'' {
+			continue
+		}
+		end := skipQuoted(source, i)
+		if end < 0 {
+			continue
+		}
+		literal := source[i : end+1]
+		start := i
+		i = end
+
+		if !strings.Contains(literal, "req") && !strings.Contains(literal, "cmd") {
+			continue
+		}
+		content, err := unquoteSourceString(literal)
+		if err != nil {
+			if requestKeyPattern.MatchString(literal) {
+				extracted = append(extracted, ExtractedRequest{
+					Line:   lineAt(source, start),
+					Origin: sourceOriginJSONLiteral,
+					Error:  fmt.Sprintf("could not parse request string literal: %v", err),
+				})
+			}
+			continue
+		}
+
+		content = strings.TrimSpace(content)
+		if !strings.HasPrefix(content, "{") {
+			continue
+		}
+		var request map[string]interface{}
+		if err := json.Unmarshal([]byte(content), &request); err != nil {
+			if requestKeyPattern.MatchString(content) {
+				extracted = append(extracted, ExtractedRequest{
+					Line:   lineAt(source, start),
+					Origin: sourceOriginJSONLiteral,
+					Error:  fmt.Sprintf("could not parse request JSON in string literal: %v", err),
+				})
+			}
+			continue
+		}
+		if !isNotecardRequest(request) {
+			continue
+		}
+		extracted = append(extracted, ExtractedRequest{
+			Line:    lineAt(source, start),
+			Origin:  sourceOriginJSONLiteral,
+			Request: request,
+		})
+	}
+	return extracted
+}
+
+// extractObjectLiteralRequests finds requests written as object literals, such as Python dicts
+// passed to card.Transaction({...}) or raw JSON objects
+func extractObjectLiteralRequests(source string, origin string) []ExtractedRequest {
+	var extracted []ExtractedRequest
+	for i := 0; i < len(source); i++ {
+		switch source[i] {
+		case '"', '\'':
+			if end := skipQuoted(source, i); end > 0 {
+				i = end
+			}
+			continue
+		case '#':
+			// Python comment
+			if origin == sourceOriginPython {
+				for i < len(source) && source[i] != '\n' {
+					i++
+				}
+			}
+			continue
+		case '{':
+		default:
+			continue
+		}
+
+		parser := &literalParser{src: source, pos: i}
+		value, ok := parser.parseValue()
+		if !ok {
+			// Report blocks that look like requests, then skip past them so nested objects aren't reported again
+			end := matchingBrace(source, i)
+			if end > i && requestKeyPattern.MatchString(source[i:end]) {
+				extracted = append(extracted, ExtractedRequest{
+					Line:   lineAt(source, i),
+					Origin: origin,
+					Error:  fmt.Sprintf("could not parse request literal near line %d", lineAt(source, min(parser.pos, len(source)-1))),
+				})
+				i = end
+			}
+			continue
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok || !isNotecardRequest(object) {
+			continue
+		}
+
+		var dynamic []string
+		request, _ := materializeSourceValue(object, "", &dynamic).(map[string]interface{})
+		extracted = append(extracted, ExtractedRequest{
+			Line:    lineAt(source, i),
+			Origin:  origin,
+			Request: request,
+			Dynamic: dynamic,
+		})
+		i = parser.pos - 1
+	}
+	return extracted
+}
+
+// requestKeyPattern matches a quoted req or cmd key, used to recognize request literals that fail to parse
+var requestKeyPattern = regexp.MustCompile(`["']\s*(?:req|cmd)\s*\\?["']\s*:`)
+
+// matchingBrace returns the index of the brace closing the one at start, skipping string literals, or -1
+func matchingBrace(source string, start int) int {
+	depth := 0
+	for i := start; i < len(source); i++ {
+		switch source[i] {
+		case '"', '\'':
+			end := skipQuoted(source, i)
+			if end < 0 {
+				return -1
+			}
+			i = end
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// unquoteSourceString unquotes a single- or double-quoted C or Python string literal. Escapes of
+// either quote character are accepted in both, and unknown escapes are kept as written, as in Python.
+func unquoteSourceString(literal string) (string, error) {
+	if len(literal) < 2 || (literal[0] != '"' && literal[0] != '\'') || literal[len(literal)-1] != literal[0] {
+		return "", fmt.Errorf("invalid string literal %s", literal)
+	}
+
+	body := literal[1 : len(literal)-1]
+	var sb strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c != '\\' {
+			sb.WriteByte(c)
+			continue
+		}
+		if i+1 >= len(body) {
+			return "", fmt.Errorf("invalid escape at end of string literal %s", literal)
+		}
+		i++
+		switch e := body[i]; e {
+		case '\'', '"', '\\', '?':
+			sb.WriteByte(e)
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '0':
+			sb.WriteByte(0)
+		case 'x', 'u':
+			digits := 2
+			if e == 'u' {
+				digits = 4
+			}
+			if i+digits >= len(body) {
+				return "", fmt.Errorf("invalid \\%c escape in string literal %s", e, literal)
+			}
+			n, err := strconv.ParseUint(body[i+1:i+1+digits], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid \\%c escape in string literal %s", e, literal)
+			}
+			sb.WriteRune(rune(n))
+			i += digits
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(e)
+		}
+	}
+	return sb.String(), nil
+}
+
+// isNotecardRequest reports whether an object has a string req or cmd property
+func isNotecardRequest(object map[string]interface{}) bool {
+	if reqType, ok := object["req"].(string); ok && reqType != "" {
+		return true
+	}
+	if cmdType, ok := object["cmd"].(string); ok && cmdType != "" {
+		return true
+	}
+	return false
+}
+
+// lineAt returns the 1-based line number of a byte offset
+func lineAt(source string, offset int) int {
+	return strings.Count(source[:offset], "\n") + 1
+}
+
+// literalParser parses JSON and Python literal syntax, treating any other expression as a dynamic value
+type literalParser struct {
+	src string
+	pos int
+}
+
+// skipSpace skips whitespace and Python comments
+func (p *literalParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// parseValue parses a single literal value
+func (p *literalParser) parseValue() (interface{}, bool) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, false
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"' || c == '\'':
+		return p.parseString()
+	}
+
+	// Keywords and numbers, falling back to an opaque expression
+	start := p.pos
+	p.skipExpression()
+	token := strings.TrimSpace(p.src[start:p.pos])
+	switch token {
+	case "":
+		return nil, false
+	case "True", "true":
+		return true, true
+	case "False", "false":
+		return false, true
+	case "None", "null":
+		return nil, true
+	}
+	if n, err := strconv.ParseFloat(token, 64); err == nil {
+		return n, true
+	}
+	return dynamicValue{}, true
+}
+
+// parseObject parses a JSON object or Python dict with string keys
+func (p *literalParser) parseObject() (interface{}, bool) {
+	p.pos++ // '{'
+	object := make(map[string]interface{})
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, false
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			return object, true
+		}
+
+		if c := p.src[p.pos]; c != '"' && c != '\'' {
+			return nil, false
+		}
+		key, ok := p.parseString()
+		if !ok {
+			return nil, false
+		}
+		keyString, ok := key.(string)
+		if !ok {
+			return nil, false
+		}
+
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != ':' {
+			return nil, false
+		}
+		p.pos++
+
+		value, ok := p.parseValue()
+		if !ok {
+			return nil, false
+		}
+		object[keyString] = value
+
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
+
+// parseArray parses a JSON array or Python list
+func (p *literalParser) parseArray() (interface{}, bool) {
+	p.pos++ // '['
+	var array []interface{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, false
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			return array, true
+		}
+
+		value, ok := p.parseValue()
+		if !ok {
+			return nil, false
+		}
+		array = append(array, value)
+
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
+
+// parseString parses a single- or double-quoted string literal
+func (p *literalParser) parseString() (interface{}, bool) {
+	end := skipQuoted(p.src, p.pos)
+	if end < 0 {
+		return nil, false
+	}
+	literal := p.src[p.pos : end+1]
+	p.pos = end + 1
+
+	s, err := unquoteSourceString(literal)
+	if err != nil {
+		return nil, false
+	}
+	return s, true
+}
+
+// skipExpression advances past an expression up to the next top-level ',', '}' or ']'
+func (p *literalParser) skipExpression() {
+	depth := 0
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; c {
+		case '"', '\'':
+			end := skipQuoted(p.src, p.pos)
+			if end < 0 {
+				p.pos = len(p.src)
+				return
+			}
+			p.pos = end
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				return
+			}
+			depth--
+		case ',', '\n':
+			if depth == 0 {
+				return
+			}
+		}
+		p.pos++
+	}
+}
@@ -0,0 +1,231 @@
+package lib
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestExtractSourceRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		source   string
+		want     []ExtractedRequest
+	}{
+		{
+			name:     "note-c chain",
+			language: "c",
+			source: `J *req = NoteNewRequest("hub.set");
+JAddStringToObject(req, "product", "com.blues.test");
+JAddStringToObject(req, "mode", "periodic");
+JAddNumberToObject(req, "outbound", 60);
+JAddBoolToObject(req, "sync", true);
+NoteRequest(req);`,
+			want: []ExtractedRequest{{
+				Line:   1,
+				Origin: sourceOriginNoteC,
+				Request: map[string]interface{}{
+					"req":      "hub.set",
+					"product":  "com.blues.test",
+					"mode":     "periodic",
+					"outbound": float64(60),
+					"sync":     true,
+				},
+			}},
+		},
+		{
+			name:     "note-c command with nested object and array",
+			language: "c",
+			source: `J *cmd = NoteNewCommand("note.add");
+J *body = JAddObjectToObject(cmd, "body");
+JAddNumberToObject(body, "temp", 21.5);
+J *files = JCreateArray();
+JAddItemToArray(files, JCreateString("data.qo"));
+JAddItemToObject(cmd, "files", files);`,
+			want: []ExtractedRequest{{
+				Line:   1,
+				Origin: sourceOriginNoteC,
+				Request: map[string]interface{}{
+					"cmd":   "note.add",
+					"body":  map[string]interface{}{"temp": 21.5},
+					"files": []interface{}{"data.qo"},
+				},
+			}},
+		},
+		{
+			name:     "note-arduino chain",
+			language: "arduino",
+			source: `if (J *req = notecard.newRequest("card.voltage")) {
+  JAddIntToObject(req, "hours", 12);
+  notecard.sendRequest(req);
+}`,
+			want: []ExtractedRequest{{
+				Line:    1,
+				Origin:  sourceOriginArduino,
+				Request: map[string]interface{}{"req": "card.voltage", "hours": float64(12)},
+			}},
+		},
+		{
+			name:     "comments are ignored",
+			language: "c",
+			source: `// J *old = NoteNewRequest("card.time");
+/* J *older = NoteNewRequest("card.status"); */
+J *req = NoteNewRequest("card.version"); // "req":"x"`,
+			want: []ExtractedRequest{{
+				Line:    3,
+				Origin:  sourceOriginNoteC,
+				Request: map[string]interface{}{"req": "card.version"},
+			}},
+		},
+		{
+			name:     "dynamic values",
+			language: "c",
+			source: `J *req = NoteNewRequest("hub.set");
+JAddStringToObject(req, "product", PRODUCT_UID);
+JAddNumberToObject(req, "outbound", interval);`,
+			want: []ExtractedRequest{{
+				Line:    1,
+				Origin:  sourceOriginNoteC,
+				Request: map[string]interface{}{"req": "hub.set", "product": "", "outbound": float64(0)},
+				Dynamic: []string{"/outbound", "/product"},
+			}},
+		},
+		{
+			name:     "python dict",
+			language: "python",
+			source: `rsp = card.Transaction({
+    'req': 'hub.set',  # comment
+    "product": productUID,
+    'sync': True,
+    'inbound': None,
+})`,
+			want: []ExtractedRequest{{
+				Line:    1,
+				Origin:  sourceOriginPython,
+				Request: map[string]interface{}{"req": "hub.set", "product": nil, "sync": true, "inbound": nil},
+				Dynamic: []string{"/product"},
+			}},
+		},
+		{
+			name:     "python escaped quotes",
+			language: "python",
+			source:   `card.Transaction({'req':'note.add','body':{'msg':'it\'s'},'sync':'yes'})`,
+			want: []ExtractedRequest{{
+				Line:    1,
+				Origin:  sourceOriginPython,
+				Request: map[string]interface{}{"req": "note.add", "body": map[string]interface{}{"msg": "it's"}, "sync": "yes"},
+			}},
+		},
+		{
+			name:     "string literal JSON",
+			language: "c",
+			source:   `NoteRequestResponseJSON("{\"req\":\"card.temp\",\"minutes\":60}\n");`,
+			want: []ExtractedRequest{{
+				Line:    1,
+				Origin:  sourceOriginJSONLiteral,
+				Request: map[string]interface{}{"req": "card.temp", "minutes": float64(60)},
+			}},
+		},
+		{
+			name:     "unparsable request literal is reported",
+			language: "json",
+			source:   `{"req": "card.temp", minutes: 60}`,
+			want: []ExtractedRequest{{
+				Line:   1,
+				Origin: sourceOriginJSONLiteral,
+				Error:  "could not parse request literal near line 1",
+			}},
+		},
+		{
+			name:     "objects without req or cmd are ignored",
+			language: "json",
+			source:   `{"file": "data.qo", "body": {"temp": 1}}`,
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractSourceRequests(tt.source, tt.language)
+			if err != nil {
+				t.Fatalf("ExtractSourceRequests() error = %v", err)
+			}
+			for i := range got {
+				sort.Strings(got[i].Dynamic)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractSourceRequests() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSourceRequestsUnsupportedLanguage(t *testing.T) {
+	if _, err := ExtractSourceRequests(`{"req":"card.version"}`, "rust"); err == nil {
+		t.Error("ExtractSourceRequests() expected an error for an unsupported language")
+	}
+}
+
+func TestUnquoteSourceString(t *testing.T) {
+	tests := []struct {
+		literal string
+		want    string
+		wantErr bool
+	}{
+		{literal: `"plain"`, want: "plain"},
+		{literal: `'single'`, want: "single"},
+		{literal: `'it\'s'`, want: "it's"},
+		{literal: `"say \"hi\""`, want: `say "hi"`},
+		{literal: `'mixed "quotes"'`, want: `mixed "quotes"`},
+		{literal: `"tab\tnewline\n"`, want: "tab\tnewline\n"},
+		{literal: `"\x41é"`, want: "Aé"},
+		{literal: `'\d+'`, want: `\d+`},
+		{literal: `"bad\x4"`, wantErr: true},
+		{literal: `"unterminated`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.literal, func(t *testing.T) {
+			got, err := unquoteSourceString(tt.literal)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unquoteSourceString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("unquoteSourceString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripCComments(t *testing.T) {
+	source := "a // line\n\"// kept\" /* block\nspans */ b"
+	want := "a        \n\"// kept\"         \n         b"
+	if got := stripCComments(source); got != want {
+		t.Errorf("stripCComments() = %q, want %q", got, want)
+	}
+}
+
+func TestIgnoreDynamicFindings(t *testing.T) {
+	item := BatchItemResult{
+		Request: "hub.set",
+		Findings: []ValidationFinding{
+			{Pointer: "/mode", Keyword: "enum", Value: "", Message: "value must be one of ..."},
+			{Pointer: "/outbound", Keyword: "type", Value: "", Message: "expected integer, but got string"},
+			{Pointer: "/prodcut", Keyword: "additionalProperties", Message: "unknown property 'prodcut' on hub.set"},
+		},
+	}
+
+	ignoreDynamicFindings(&item, []string{"/mode", "/outbound", "/prodcut"})
+
+	if item.Valid {
+		t.Fatal("ignoreDynamicFindings() marked the item valid despite an unknown property")
+	}
+	var pointers []string
+	for _, finding := range item.Findings {
+		pointers = append(pointers, finding.Pointer)
+	}
+	if want := []string{"/outbound", "/prodcut"}; !reflect.DeepEqual(pointers, want) {
+		t.Errorf("ignoreDynamicFindings() kept %v, want %v", pointers, want)
+	}
+}
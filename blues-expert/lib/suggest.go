@@ -0,0 +1,146 @@
+package lib
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// suggestClosest returns the candidate that most closely matches name, or "" if none is close enough.
+// Candidates made of the same tokens in a different order or with different separators
+// (e.g. "card.location_mode" for "card.location.mode") are preferred over edit-distance matches.
+func suggestClosest(name string, candidates []string) string {
+	if name == "" || len(candidates) == 0 {
+		return ""
+	}
+
+	normalizedName := strings.ToLower(name)
+	nameTokens := suggestionTokens(normalizedName)
+
+	best := ""
+	bestScore := -1.0
+	for _, candidate := range candidates {
+		if candidate == name {
+			return ""
+		}
+		normalizedCandidate := strings.ToLower(candidate)
+
+		var score float64
+		switch {
+		case normalizedCandidate == normalizedName:
+			score = 0
+		case strings.Join(nameTokens, "") == strings.Join(suggestionTokens(normalizedCandidate), ""):
+			score = 0.25
+		case sameTokens(nameTokens, suggestionTokens(normalizedCandidate)):
+			score = 0.5
+		default:
+			distance := levenshtein(normalizedName, normalizedCandidate)
+			if distance > maxSuggestionDistance(name) {
+				continue
+			}
+			score = float64(distance)
+		}
+
+		if bestScore < 0 || score < bestScore || (score == bestScore && candidate < best) {
+			best = candidate
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// maxSuggestionDistance returns the largest edit distance accepted for a suggestion, scaled by name length.
+// The distance must stay below half the name's length, so short names only match near-identical candidates.
+func maxSuggestionDistance(name string) int {
+	return min(max(2, len(name)/3), (len(name)-1)/2)
+}
+
+// suggestionTokens splits a name into lowercase tokens on '.', '_' and '-' separators
+func suggestionTokens(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return r == '.' || r == '_' || r == '-'
+	})
+}
+
+// sameTokens reports whether two token lists contain the same tokens, ignoring order
+func sameTokens(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// cachedAPINames returns the names of all APIs with a request schema in a schema directory
+func cachedAPINames(dir string) []string {
+	cacheFiles, err := filepath.Glob(filepath.Join(dir, "*.req.notecard.api.json"))
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(cacheFiles))
+	for _, file := range cacheFiles {
+		names = append(names, strings.TrimSuffix(filepath.Base(file), ".req.notecard.api.json"))
+	}
+	return names
+}
+
+// requestSchemaProperties returns the property names defined by a request schema file,
+// excluding the implicit req/cmd properties
+func requestSchemaProperties(schemaPath string) []string {
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil
+	}
+
+	var schemaData map[string]interface{}
+	if err := json.Unmarshal(data, &schemaData); err != nil {
+		return nil
+	}
+
+	props, _ := schemaData["properties"].(map[string]interface{})
+	names := make([]string, 0, len(props))
+	for name := range props {
+		if name == "req" || name == "cmd" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,76 @@
+package lib
+
+import "testing"
+
+func TestSuggestClosest(t *testing.T) {
+	apis := []string{"card.location", "card.location.mode", "card.time", "card.version", "hub.get", "hub.set", "hub.status", "note.add", "note.get"}
+	tests := []struct {
+		desc       string
+		candidates []string
+		name       string
+		want       string
+	}{
+		{"typical typo", apis, "hub.st", "hub.set"},
+		{"transposed letters", apis, "card.verison", "card.version"},
+		{"different case", apis, "Hub.Set", "hub.set"},
+		{"different separator", apis, "card.location_mode", "card.location.mode"},
+		{"tokens in another order", apis, "mode.location.card", "card.location.mode"},
+		{"exact match", apis, "hub.set", ""},
+		{"too far", apis, "web.post", ""},
+		{"short name close enough", []string{"mode", "seconds"}, "mod", "mode"},
+		{"short name without spurious hint", []string{"sid", "ssid"}, "id", ""},
+		{"one letter", []string{"a", "b"}, "c", ""},
+		{"tie broken alphabetically", []string{"hat", "bat"}, "cat", "bat"},
+		{"no candidates", nil, "hub.set", ""},
+		{"empty name", apis, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := suggestClosest(tt.name, tt.candidates); got != tt.want {
+				t.Errorf("suggestClosest(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxSuggestionDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"a", 0},
+		{"id", 0},
+		{"mod", 1},
+		{"mode", 1},
+		{"hub.st", 2},
+		{"card.verison", 4},
+		{"card.location.mode", 6},
+	}
+	for _, tt := range tests {
+		if got := maxSuggestionDistance(tt.name); got != tt.want {
+			t.Errorf("maxSuggestionDistance(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"hub.set", "hub.set", 0},
+		{"hub.st", "hub.set", 1},
+		{"kitten", "sitting", 3},
+		{"µA", "mA", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := levenshtein(tt.b, tt.a); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Notecard hardware families used by the "skus" keyword of the Notecard API schema
+const (
+	skuFamilyCell     = "CELL"
+	skuFamilyCellWiFi = "CELL+WIFI"
+	skuFamilyLoRa     = "LORA"
+	skuFamilyWiFi     = "WIFI"
+)
+
+// notecardSKUFamilies maps Notecard product SKUs to the hardware family used by the API schema.
+// Other SKUs can be validated by passing their family name directly.
+var notecardSKUFamilies = map[string]string{
+	// Notecard Cellular
+	"NOTE-NBNA":     skuFamilyCell,
+	"NOTE-NBGL":     skuFamilyCell,
+	"NOTE-NBNA-500": skuFamilyCell,
+	"NOTE-NBGL-500": skuFamilyCell,
+	"NOTE-WBNA-500": skuFamilyCell,
+	"NOTE-WBEX-500": skuFamilyCell,
+	"NOTE-WBNAN":    skuFamilyCell,
+	"NOTE-WBEXN":    skuFamilyCell,
+	"NOTE-WBGLN":    skuFamilyCell,
+	"NOTE-MBNAN":    skuFamilyCell,
+	"NOTE-MBGLN":    skuFamilyCell,
+	"NOTE-MBGLB":    skuFamilyCell,
+	// Notecard Cell+WiFi
+	"NOTE-WBNAW": skuFamilyCellWiFi,
+	"NOTE-WBEXW": skuFamilyCellWiFi,
+	// Notecard WiFi
+	"NOTE-ESP32": skuFamilyWiFi,
+	// Notecard LoRa
+	"NOTE-LWUS": skuFamilyLoRa,
+	"NOTE-LWEU": skuFamilyLoRa,
+}
+
+// ValidationTarget describes the Notecard hardware and firmware a request will be sent to.
+// Empty fields are not checked.
+type ValidationTarget struct {
+	SKU      string `json:"sku,omitempty"`
+	Firmware string `json:"firmware,omitempty"`
+}
+
+// IsZero reports whether no target hardware or firmware was specified
+func (t ValidationTarget) IsZero() bool {
+	return t.SKU == "" && t.Firmware == ""
+}
+
+// ResolveSKUFamily returns the schema hardware family for a Notecard product SKU.
+// Family names (CELL, CELL+WIFI, LORA, WIFI) are also accepted as-is.
+func ResolveSKUFamily(sku string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(sku))
+	switch normalized {
+	case skuFamilyCell, skuFamilyCellWiFi, skuFamilyLoRa, skuFamilyWiFi:
+		return normalized, nil
+	}
+	if family, ok := notecardSKUFamilies[normalized]; ok {
+		return family, nil
+	}
+
+	known := make([]string, 0, len(notecardSKUFamilies))
+	for name := range notecardSKUFamilies {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	if suggestion := suggestClosest(normalized, known); suggestion != "" {
+		return "", fmt.Errorf("unknown Notecard SKU '%s' — did you mean '%s'?", sku, suggestion)
+	}
+	return "", fmt.Errorf("unknown Notecard SKU '%s'. Known SKUs are: %s (or a family: CELL, CELL+WIFI, LORA, WIFI)", sku, strings.Join(known, ", "))
+}
+
+// parseFirmwareVersion parses a Notecard firmware version such as "9.1.1", "v8.1.4" or
+// "notecard-9.1.1.17168" into its numeric components
+func parseFirmwareVersion(version string) ([]int, error) {
+	trimmed := strings.TrimLeftFunc(strings.TrimSpace(version), func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid firmware version '%s': expected a version such as '9.1.1'", version)
+	}
+
+	var components []int
+	for _, part := range strings.Split(trimmed, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid firmware version '%s': expected a version such as '9.1.1'", version)
+		}
+		components = append(components, n)
+	}
+	return components, nil
+}
+
+// compareFirmwareVersions compares two parsed versions, treating missing components as zero.
+// Build numbers are only compared when both versions include them.
+func compareFirmwareVersions(a, b []int) int {
+	length := 3
+	if len(a) > 3 && len(b) > 3 {
+		length = min(len(a), len(b))
+	}
+
+	for i := 0; i < length; i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// resolvedTarget is a validation target with its SKU resolved to a family and its firmware parsed
+type resolvedTarget struct {
+	ValidationTarget
+	family   string
+	firmware []int
+}
+
+// resolveTarget checks the target SKU and firmware version
+func resolveTarget(target ValidationTarget) (*resolvedTarget, error) {
+	resolved := &resolvedTarget{ValidationTarget: target}
+	if target.SKU != "" {
+		family, err := ResolveSKUFamily(target.SKU)
+		if err != nil {
+			return nil, err
+		}
+		resolved.family = family
+	}
+	if target.Firmware != "" {
+		firmware, err := parseFirmwareVersion(target.Firmware)
+		if err != nil {
+			return nil, err
+		}
+		resolved.firmware = firmware
+	}
+	return resolved, nil
+}
+
+// describe names the target SKU for use in messages, e.g. "NOTE-MBGLB (CELL)"
+func (t *resolvedTarget) describe() string {
+	if strings.EqualFold(t.SKU, t.family) {
+		return t.family
+	}
+	return fmt.Sprintf("%s (%s)", t.SKU, t.family)
+}
+
+// supportsSKUs reports whether the target family is in a schema "skus" list. An empty list means all SKUs.
+func (t *resolvedTarget) supportsSKUs(skus []string) bool {
+	if t.family == "" || len(skus) == 0 {
+		return true
+	}
+	for _, sku := range skus {
+		if strings.EqualFold(sku, t.family) {
+			return true
+		}
+	}
+	return false
+}
+
+// supportsVersion reports whether the target firmware is at least the schema "apiVersion"
+func (t *resolvedTarget) supportsVersion(apiVersion string) bool {
+	if t.firmware == nil || apiVersion == "" {
+		return true
+	}
+	required, err := parseFirmwareVersion(apiVersion)
+	if err != nil {
+		return true
+	}
+	return compareFirmwareVersions(t.firmware, required) >= 0
+}
+
+// apiVersion returns the firmware release documented by the release's main schema, if known
+func (r *schemaRelease) apiVersion() string {
+	data, err := os.ReadFile(r.getCachePath(r.url))
+	if err != nil {
+		return ""
+	}
+	var schemaData map[string]interface{}
+	if err := json.Unmarshal(data, &schemaData); err != nil {
+		return ""
+	}
+	apiVersion, _ := schemaData["apiVersion"].(string)
+	return apiVersion
+}
+
+// checkTargetCompatibility returns a finding for the request, each property and each property value
+// that is not supported by the target SKU or firmware version. An apiVersion equal to the one of the
+// schema as a whole only records the firmware release the schema documents, so it is not treated as
+// a minimum version. Firmware checks are therefore limited to what the schema records: requests,
+// properties and values without their own apiVersion are never flagged.
+func (r *schemaRelease) checkTargetCompatibility(reqType string, reqMap map[string]interface{}, target *resolvedTarget) []ValidationFinding {
+	api := r.requestAPI(reqType)
+	if api == nil {
+		return nil
+	}
+	documentedVersion := r.apiVersion()
+	minimumVersion := func(apiVersion string) string {
+		return minimumFirmwareVersion(apiVersion, documentedVersion)
+	}
+
+	var findings []ValidationFinding
+	if !target.supportsSKUs(api.SKUs) {
+		findings = append(findings, ValidationFinding{
+			Pointer: "",
+			Keyword: "skus",
+			Message: fmt.Sprintf("%s is not supported on %s Notecards; it is only available on: %s", reqType, target.describe(), strings.Join(api.SKUs, ", ")),
+		})
+	}
+	if apiVersion := minimumVersion(api.APIVersion); !target.supportsVersion(apiVersion) {
+		findings = append(findings, ValidationFinding{
+			Pointer: "",
+			Keyword: "apiVersion",
+			Message: fmt.Sprintf("%s requires Notecard firmware %s or later, but the target firmware is %s", reqType, apiVersion, target.Firmware),
+		})
+	}
+
+	for name, value := range reqMap {
+		property, ok := api.Properties[name]
+		if !ok {
+			continue
+		}
+		pointer := "/" + name
+
+		if !target.supportsSKUs(property.SKUs) {
+			findings = append(findings, ValidationFinding{
+				Pointer: pointer,
+				Value:   value,
+				Keyword: "skus",
+				Message: fmt.Sprintf("'%s' on %s is not supported on %s Notecards; it is only available on: %s", name, reqType, target.describe(), strings.Join(property.SKUs, ", ")),
+			})
+		}
+		if apiVersion := minimumVersion(property.APIVersion); !target.supportsVersion(apiVersion) {
+			findings = append(findings, ValidationFinding{
+				Pointer: pointer,
+				Value:   value,
+				Keyword: "apiVersion",
+				Message: fmt.Sprintf("'%s' on %s requires Notecard firmware %s or later, but the target firmware is %s", name, reqType, apiVersion, target.Firmware),
+			})
+		}
+
+		stringValue, ok := value.(string)
+		if !ok {
+			continue
+		}
+		for _, subDesc := range property.SubDescriptions {
+			if subDesc.Const != stringValue {
+				continue
+			}
+			if !target.supportsSKUs(subDesc.SKUs) {
+				findings = append(findings, ValidationFinding{
+					Pointer: pointer,
+					Value:   value,
+					Keyword: "skus",
+					Message: fmt.Sprintf("'%s' value '%s' on %s is not supported on %s Notecards; it is only available on: %s", name, stringValue, reqType, target.describe(), strings.Join(subDesc.SKUs, ", ")),
+				})
+			}
+			if apiVersion := minimumVersion(subDesc.APIVersion); !target.supportsVersion(apiVersion) {
+				findings = append(findings, ValidationFinding{
+					Pointer: pointer,
+					Value:   value,
+					Keyword: "apiVersion",
+					Message: fmt.Sprintf("'%s' value '%s' on %s requires Notecard firmware %s or later, but the target firmware is %s", name, stringValue, reqType, apiVersion, target.Firmware),
+				})
+			}
+		}
+	}
+
+	// Keep findings in a stable order, as request properties are visited in map order
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Pointer < findings[j].Pointer
+	})
+	return findings
+}
+
+// firmwareCheckWarning returns a warning when a target firmware version is given but the schema records
+// no minimum firmware version for the request, the properties it uses or their values, so that the
+// firmware version could not be checked. It returns "" otherwise.
+func firmwareCheckWarning(reqMap map[string]interface{}, schemaURL string, target ValidationTarget) string {
+	if target.Firmware == "" {
+		return ""
+	}
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+	reqType, _ := reqMap["req"].(string)
+	if reqType == "" {
+		reqType, _ = reqMap["cmd"].(string)
+	}
+	release := schemaReleaseForURL(schemaURL)
+	api := release.requestAPI(reqType)
+	if api == nil {
+		return ""
+	}
+
+	documentedVersion := release.apiVersion()
+	if minimumFirmwareVersion(api.APIVersion, documentedVersion) != "" {
+		return ""
+	}
+	for name, value := range reqMap {
+		property, ok := api.Properties[name]
+		if !ok {
+			continue
+		}
+		if minimumFirmwareVersion(property.APIVersion, documentedVersion) != "" {
+			return ""
+		}
+		stringValue, _ := value.(string)
+		for _, subDesc := range property.SubDescriptions {
+			if subDesc.Const == stringValue && minimumFirmwareVersion(subDesc.APIVersion, documentedVersion) != "" {
+				return ""
+			}
+		}
+	}
+	return fmt.Sprintf("firmware %s was not checked: the schema records no minimum firmware version for %s or the properties and values it uses", target.Firmware, reqType)
+}
+
+// minimumFirmwareVersion returns the minimum firmware version recorded by an apiVersion, or "" if it
+// only records the firmware release documented by the schema as a whole
+func minimumFirmwareVersion(apiVersion string, documentedVersion string) string {
+	if apiVersion == documentedVersion {
+		return ""
+	}
+	return apiVersion
+}
+
+// requestAPI returns the documentation of an API from its request schema, or nil if it cannot be read
+func (r *schemaRelease) requestAPI(reqType string) *APIEntry {
+	data, err := os.ReadFile(r.requestSchemaPath(reqType))
+	if err != nil {
+		return nil
+	}
+	var schemaData map[string]interface{}
+	if err := json.Unmarshal(data, &schemaData); err != nil {
+		return nil
+	}
+	return extractAPIFromSchema(reqType, schemaData)
+}
+
+// describeValidationTarget summarizes a target for use in messages, e.g. "SKU NOTE-MBGLB, firmware 8.1.4"
+func describeValidationTarget(target ValidationTarget) string {
+	var parts []string
+	if target.SKU != "" {
+		parts = append(parts, "SKU "+target.SKU)
+	}
+	if target.Firmware != "" {
+		parts = append(parts, "firmware "+target.Firmware)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,30 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFirmwareCheckWarning(t *testing.T) {
+	transport := map[string]interface{}{"req": "card.transport", "method": "wifi-cell-ntn"}
+	target := ValidationTarget{SKU: "NOTE-WBNAW", Firmware: "5.0.0"}
+	if err := ValidateNotecardRequestForTarget(transport, "", target); err != nil {
+		t.Fatalf("ValidateNotecardRequestForTarget() error = %v", err)
+	}
+	if warning := firmwareCheckWarning(transport, "", target); !strings.Contains(warning, "firmware 5.0.0 was not checked") {
+		t.Errorf("firmwareCheckWarning() = %q, want a warning that firmware 5.0.0 was not checked", warning)
+	}
+
+	// ntn.status records its own minimum firmware version, so it is checked
+	status := map[string]interface{}{"req": "ntn.status"}
+	if err := ValidateNotecardRequestForTarget(status, "", target); err == nil || !strings.Contains(err.Error(), "requires Notecard firmware 7.2.2") {
+		t.Errorf("ValidateNotecardRequestForTarget() error = %v, want ntn.status to require firmware 7.2.2", err)
+	}
+	if warning := firmwareCheckWarning(status, "", target); warning != "" {
+		t.Errorf("firmwareCheckWarning() = %q, want none", warning)
+	}
+
+	if warning := firmwareCheckWarning(transport, "", ValidationTarget{SKU: "NOTE-WBNAW"}); warning != "" {
+		t.Errorf("firmwareCheckWarning() without a firmware version = %q, want none", warning)
+	}
+}
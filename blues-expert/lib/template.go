@@ -0,0 +1,520 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// templateMetadataBytes is the metadata the Notecard adds to each Note of a non-compact template. The
+// templates guide reports 40 bytes per Note for a 10-byte template body, and 72 with a 32-byte payload.
+const templateMetadataBytes = 30
+
+// compactStringLimit is the longest string value, in bytes, that a compact template accepts
+const compactStringLimit = 255
+
+// templateNumberType describes a numeric type hint of a Note template, e.g. 14.1 for a 4-byte float
+type templateNumberType struct {
+	name     string
+	bytes    int
+	float    bool
+	unsigned bool
+}
+
+// templateNumberTypes are the numeric type hints of Note templates, keyed by their hint as written
+// in a template body. Unsigned integers are available as of firmware v3.3.1.
+var templateNumberTypes = map[string]templateNumberType{
+	"11":   {name: "int8", bytes: 1},
+	"12":   {name: "int16", bytes: 2},
+	"13":   {name: "int24", bytes: 3},
+	"14":   {name: "int32", bytes: 4},
+	"18":   {name: "int64", bytes: 8},
+	"21":   {name: "uint8", bytes: 1, unsigned: true},
+	"22":   {name: "uint16", bytes: 2, unsigned: true},
+	"23":   {name: "uint24", bytes: 3, unsigned: true},
+	"24":   {name: "uint32", bytes: 4, unsigned: true},
+	"12.1": {name: "float16", bytes: 2, float: true},
+	"14.1": {name: "float32", bytes: 4, float: true},
+	"18.1": {name: "float64", bytes: 8, float: true},
+}
+
+// compactTemplateKeywords are the template fields that restore Note metadata in compact templates,
+// with the type hints they accept
+var compactTemplateKeywords = map[string][]string{
+	"_lat":   {"12.1", "14.1", "18.1"},
+	"_lon":   {"12.1", "14.1", "18.1"},
+	"_ltime": {"14"},
+	"_time":  {"14"},
+}
+
+// TemplateField is a field of a Note template and its storage size
+type TemplateField struct {
+	Pointer string `json:"pointer" jsonschema:"The JSON pointer of the field in the Note body"`
+	Type    string `json:"type" jsonschema:"The field type, e.g. 'bool', 'int16', 'float32', 'string(42)' for a fixed-length string or 'string' for a variable-length string"`
+	Bytes   int    `json:"bytes" jsonschema:"The bytes the field takes in each Note. Variable-length strings and arrays are sized by the sample Note, if any"`
+}
+
+// TemplateCheckResult is the result of checking a Note template and a sample Note against it
+type TemplateCheckResult struct {
+	Valid         bool                `json:"valid" jsonschema:"Whether the template is valid and the sample Note, if any, fits it without losing data"`
+	Format        string              `json:"format,omitempty" jsonschema:"The template format, e.g. 'compact'"`
+	Fields        []TemplateField     `json:"fields,omitempty" jsonschema:"The fields of the template"`
+	BodyBytes     int                 `json:"body_bytes" jsonschema:"The bytes taken by the body of each Note"`
+	PayloadBytes  int                 `json:"payload_bytes,omitempty" jsonschema:"The maximum payload bytes set by the template 'length', or the payload bytes of the sample Note"`
+	MetadataBytes int                 `json:"metadata_bytes,omitempty" jsonschema:"The metadata the Notecard adds to each Note of a non-compact template"`
+	NoteBytes     int                 `json:"note_bytes" jsonschema:"The estimated bytes transmitted to Notehub per Note before compression: body, payload and metadata, as reported by note.template"`
+	Errors        []ValidationFinding `json:"errors,omitempty" jsonschema:"Problems that make the template invalid or that lose data from the sample Note"`
+	Warnings      []ValidationFinding `json:"warnings,omitempty" jsonschema:"Firmware requirements and values that are stored differently than sent"`
+}
+
+// templateChecker collects the fields and findings of a template check
+type templateChecker struct {
+	compact bool
+	result  *TemplateCheckResult
+}
+
+// CheckNoteTemplate validates a Note template body against the Notecard template type rules and, if a
+// sample Note is given, checks that the Note fits the template. The template may be a body or a whole
+// note.template request, and the Note a body or a whole note.add request.
+func CheckNoteTemplate(template string, note string) (*TemplateCheckResult, error) {
+	templateReq, err := decodeTemplateJSON(template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template JSON: %v", err)
+	}
+	templateBody, format, length := templateReq, "", 0
+	if isRequestFor(templateReq, "note.template") {
+		templateBody, _ = templateReq["body"].(map[string]interface{})
+		format, _ = templateReq["format"].(string)
+		if n, ok := templateReq["length"].(json.Number); ok {
+			value, _ := n.Int64()
+			length = int(value)
+		}
+	}
+
+	checker := &templateChecker{
+		compact: format == "compact",
+		result:  &TemplateCheckResult{Format: format, PayloadBytes: length},
+	}
+	checker.checkTemplateObject("", templateBody)
+	checker.result.BodyBytes = checker.fieldBytes()
+
+	if note != "" {
+		noteReq, err := decodeTemplateJSON(note)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Note JSON: %v", err)
+		}
+		noteBody, payload := noteReq, ""
+		if isRequestFor(noteReq, "note.add") {
+			noteBody, _ = noteReq["body"].(map[string]interface{})
+			payload, _ = noteReq["payload"].(string)
+		}
+		checker.checkNoteObject("", templateBody, noteBody)
+		checker.checkPayload(payload, length)
+		checker.result.BodyBytes = checker.fieldBytes()
+	}
+
+	if !checker.compact {
+		checker.result.MetadataBytes = templateMetadataBytes
+	}
+	checker.result.NoteBytes = checker.result.BodyBytes + checker.result.PayloadBytes + checker.result.MetadataBytes
+	checker.result.Valid = len(checker.result.Errors) == 0
+	return checker.result, nil
+}
+
+// decodeTemplateJSON decodes a JSON object, keeping numbers as written so that type hints such as
+// 14 and 14.1 and large 8-byte integers are preserved
+func decodeTemplateJSON(data string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	return object, nil
+}
+
+// isRequestFor reports whether a JSON object is a request for the given API rather than a Note body
+func isRequestFor(object map[string]interface{}, api string) bool {
+	name, _ := object["req"].(string)
+	if name == "" {
+		name, _ = object["cmd"].(string)
+	}
+	return name == api
+}
+
+// checkTemplateObject checks the fields of a template object
+func (c *templateChecker) checkTemplateObject(pointer string, object map[string]interface{}) {
+	for _, name := range sortedKeys(object) {
+		fieldPointer := pointer + "/" + name
+		if allowed, ok := compactTemplateKeywords[name]; ok && pointer == "" {
+			c.checkCompactKeyword(fieldPointer, name, object[name], allowed)
+			continue
+		}
+		c.checkTemplateValue(fieldPointer, object[name])
+	}
+}
+
+// checkTemplateValue checks a template type hint, recording the field it defines
+func (c *templateChecker) checkTemplateValue(pointer string, hint interface{}) {
+	switch value := hint.(type) {
+	case bool:
+		if !value {
+			c.addError(pointer, value, "type", "boolean fields must be specified as true in a template", "true")
+			return
+		}
+		c.addField(pointer, "bool", 1)
+	case json.Number:
+		numberType, ok := templateNumberTypes[value.String()]
+		if !ok {
+			c.addError(pointer, value, "type", fmt.Sprintf("%s is not a template number type. Use 11, 12, 13, 14 or 18 for signed integers, 21, 22, 23 or 24 for unsigned integers, and 12.1, 14.1 or 18.1 for floats", value), suggestTemplateNumberType(value))
+			return
+		}
+		if numberType.unsigned {
+			c.addWarning(pointer, value, "firmware", fmt.Sprintf("%s integers require Notecard firmware v3.3.1 or later", numberType.name))
+		}
+		c.addField(pointer, numberType.name, numberType.bytes)
+	case string:
+		if maxLength, err := strconv.Atoi(value); err == nil && maxLength > 0 {
+			c.addField(pointer, fmt.Sprintf("string(%d)", maxLength), maxLength)
+			return
+		}
+		c.addWarning(pointer, value, "firmware", "non-numeric strings define variable-length string fields, which require Notecard firmware v3.2.1 or later. Use a numeric string such as \"42\" to set a maximum length for older firmware")
+		c.addField(pointer, "string", 1)
+	case []interface{}:
+		if len(value) == 0 {
+			c.addError(pointer, value, "type", "arrays must contain the type hint of their elements", "")
+			return
+		}
+		if _, ok := value[0].(json.Number); ok && len(value) == 1 {
+			c.addWarning(pointer, nil, "firmware", "single-number arrays define variable-length arrays, which require Notecard firmware v9.1.1 or later")
+		}
+		for i, element := range value {
+			c.checkTemplateValue(fmt.Sprintf("%s/%d", pointer, i), element)
+		}
+	case map[string]interface{}:
+		c.checkTemplateObject(pointer, value)
+	default:
+		c.addError(pointer, value, "type", "template fields must be a boolean, number, string, array or object type hint", "")
+	}
+}
+
+// checkCompactKeyword checks a field that restores Note metadata in compact templates
+func (c *templateChecker) checkCompactKeyword(pointer string, name string, hint interface{}, allowed []string) {
+	number, ok := hint.(json.Number)
+	if !ok || !slices.Contains(allowed, number.String()) {
+		c.addError(pointer, hint, "type", fmt.Sprintf("%s must be one of %s", name, strings.Join(allowed, ", ")), allowed[len(allowed)-1])
+		return
+	}
+	if !c.compact {
+		c.addWarning(pointer, hint, "format", fmt.Sprintf("%s only restores Note metadata in templates with \"format\":\"compact\"; otherwise it is an ordinary field", name))
+	}
+	numberType := templateNumberTypes[number.String()]
+	c.addField(pointer, numberType.name, numberType.bytes)
+}
+
+// checkNoteObject checks the fields of a sample Note object against the template object
+func (c *templateChecker) checkNoteObject(pointer string, template map[string]interface{}, note map[string]interface{}) {
+	for _, name := range sortedKeys(note) {
+		fieldPointer := pointer + "/" + name
+		hint, ok := template[name]
+		if !ok {
+			c.addError(fieldPointer, note[name], "dropped", "the field is not in the template, so it is dropped from the Note", "")
+			continue
+		}
+		if isEmptyNoteValue(note[name]) {
+			c.addWarning(fieldPointer, note[name], "omitempty", "empty values (false, 0 and \"\") are omitted from templated Notes. Use \"full\":true in note.add to keep them")
+		}
+		c.checkNoteValue(fieldPointer, hint, note[name])
+	}
+}
+
+// checkNoteValue checks a sample Note value against its template type hint
+func (c *templateChecker) checkNoteValue(pointer string, hint interface{}, value interface{}) {
+	switch hint := hint.(type) {
+	case bool:
+		if _, ok := value.(bool); !ok {
+			c.addError(pointer, value, "type", "the template defines a boolean", "")
+		}
+	case json.Number:
+		number, ok := value.(json.Number)
+		if !ok {
+			c.addError(pointer, value, "type", "the template defines a number", "")
+			return
+		}
+		if numberType, ok := templateNumberTypes[hint.String()]; ok {
+			c.checkNoteNumber(pointer, numberType, number)
+		}
+	case string:
+		text, ok := value.(string)
+		if !ok {
+			c.addError(pointer, value, "type", "the template defines a string", "")
+			return
+		}
+		if maxLength, err := strconv.Atoi(hint); err == nil && maxLength > 0 {
+			if len(text) > maxLength {
+				c.addError(pointer, value, "truncated", fmt.Sprintf("the string is %d bytes but the template allows %d, so it is truncated", len(text), maxLength), "")
+			}
+			return
+		}
+		if c.compact && len(text) > compactStringLimit {
+			c.addError(pointer, value, "maxLength", fmt.Sprintf("compact templates only support strings up to %d bytes; note.add fails with {template-incompatible}", compactStringLimit), "")
+		}
+		c.setFieldBytes(pointer, 1+len(text))
+	case []interface{}:
+		elements, ok := value.([]interface{})
+		if !ok {
+			c.addError(pointer, value, "type", "the template defines an array", "")
+			return
+		}
+		// An empty template array is reported by the template check, and drops every element
+		variable := false
+		if len(hint) == 1 {
+			_, variable = hint[0].(json.Number)
+		}
+		for i, element := range elements {
+			elementPointer := fmt.Sprintf("%s/%d", pointer, i)
+			switch {
+			case variable:
+				c.checkNoteValue(elementPointer, hint[0], element)
+			case i < len(hint):
+				c.checkNoteValue(elementPointer, hint[i], element)
+			default:
+				c.addError(elementPointer, element, "dropped", fmt.Sprintf("the template array has %d element(s), so this element is dropped", len(hint)), "")
+			}
+		}
+		if variable && len(elements) > 1 {
+			c.scaleFieldBytes(pointer+"/0", len(elements))
+		}
+	case map[string]interface{}:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			c.addError(pointer, value, "type", "the template defines an object", "")
+			return
+		}
+		c.checkNoteObject(pointer, hint, object)
+	}
+}
+
+// checkNoteNumber checks that a sample Note number fits the range and precision of its template type
+func (c *templateChecker) checkNoteNumber(pointer string, numberType templateNumberType, number json.Number) {
+	value, err := number.Float64()
+	if err != nil {
+		c.addError(pointer, number, "type", fmt.Sprintf("invalid number: %v", err), "")
+		return
+	}
+
+	if numberType.float {
+		switch numberType.bytes {
+		case 2:
+			if math.Abs(value) > 65504 {
+				c.addError(pointer, number, "range", "the value is out of range for a 2-byte float (±65504)", "14.1")
+			} else if stored := roundFloat16(value); stored != value {
+				c.addWarning(pointer, number, "precision", fmt.Sprintf("a 2-byte float stores this value as %v", stored))
+			}
+		case 4:
+			if math.Abs(value) > math.MaxFloat32 {
+				c.addError(pointer, number, "range", "the value is out of range for a 4-byte float", "18.1")
+			}
+		}
+		return
+	}
+
+	if value != math.Trunc(value) {
+		c.addWarning(pointer, number, "precision", fmt.Sprintf("the template defines an %s, so the fractional part is truncated", numberType.name))
+		value = math.Trunc(value)
+	}
+	bits := float64(numberType.bytes * 8)
+	minimum, maximum := -math.Pow(2, bits-1), math.Pow(2, bits-1)-1
+	if numberType.unsigned {
+		minimum, maximum = 0, math.Pow(2, bits)-1
+	}
+	if numberType.bytes == 8 {
+		if math.Abs(value) >= math.Pow(2, 63) {
+			c.addError(pointer, number, "range", "the value is out of range for an int64", "")
+		}
+		return
+	}
+	if value < minimum || value > maximum {
+		c.addError(pointer, number, "range", fmt.Sprintf("the value is out of range for an %s (%.0f to %.0f) and is truncated", numberType.name, minimum, maximum), suggestTemplateIntegerType(value, numberType.unsigned))
+	}
+}
+
+// checkPayload checks the payload of a sample Note against the template's payload length
+func (c *templateChecker) checkPayload(payload string, length int) {
+	if payload == "" {
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		c.addError("/payload", nil, "payload", "the payload must be base64-encoded", "")
+		return
+	}
+	if length > 0 && len(data) > length {
+		c.addError("/payload", nil, "payload", fmt.Sprintf("the payload is %d bytes but the template 'length' allows %d", len(data), length), "")
+	}
+	if length == 0 {
+		c.result.PayloadBytes = len(data)
+	}
+}
+
+// addField records a template field
+func (c *templateChecker) addField(pointer string, fieldType string, size int) {
+	c.result.Fields = append(c.result.Fields, TemplateField{Pointer: pointer, Type: fieldType, Bytes: size})
+}
+
+// setFieldBytes sets the size of a variable-length field from the sample Note
+func (c *templateChecker) setFieldBytes(pointer string, size int) {
+	for i := range c.result.Fields {
+		if c.result.Fields[i].Pointer == pointer {
+			c.result.Fields[i].Bytes = size
+		}
+	}
+}
+
+// scaleFieldBytes sizes the element of a variable-length array by the number of elements in the sample Note
+func (c *templateChecker) scaleFieldBytes(pointer string, count int) {
+	for i := range c.result.Fields {
+		if c.result.Fields[i].Pointer == pointer {
+			c.result.Fields[i].Bytes = templateNumberBytes(c.result.Fields[i].Type) * count
+		}
+	}
+}
+
+// fieldBytes returns the total size of the template fields
+func (c *templateChecker) fieldBytes() int {
+	total := 0
+	for _, field := range c.result.Fields {
+		total += field.Bytes
+	}
+	return total
+}
+
+// addError records a problem that makes the template invalid or loses data from the sample Note
+func (c *templateChecker) addError(pointer string, value interface{}, keyword string, message string, suggestion string) {
+	c.result.Errors = append(c.result.Errors, ValidationFinding{Pointer: pointer, Value: value, Keyword: keyword, Message: message, Suggestion: suggestion})
+}
+
+// addWarning records a firmware requirement or a value stored differently than sent
+func (c *templateChecker) addWarning(pointer string, value interface{}, keyword string, message string) {
+	c.result.Warnings = append(c.result.Warnings, ValidationFinding{Pointer: pointer, Value: value, Keyword: keyword, Message: message})
+}
+
+// templateNumberBytes returns the size of a numeric template field type, e.g. 4 for "float32"
+func templateNumberBytes(fieldType string) int {
+	for _, numberType := range templateNumberTypes {
+		if numberType.name == fieldType {
+			return numberType.bytes
+		}
+	}
+	return 0
+}
+
+// suggestTemplateNumberType suggests the type hint that was probably meant for an invalid number,
+// e.g. 14 for 4 or 14.1 for 4.1
+func suggestTemplateNumberType(number json.Number) string {
+	value, err := number.Float64()
+	if err != nil {
+		return ""
+	}
+	if value != math.Trunc(value) {
+		for _, hint := range []string{"12.1", "14.1", "18.1"} {
+			if size, _ := strconv.Atoi(hint[1:2]); int(value) == size {
+				return hint
+			}
+		}
+		return "14.1"
+	}
+	if _, ok := templateNumberTypes[fmt.Sprintf("1%d", int(value))]; ok && value >= 1 && value <= 8 {
+		return fmt.Sprintf("1%d", int(value))
+	}
+	return ""
+}
+
+// suggestTemplateIntegerType returns the smallest integer type hint that holds a value
+func suggestTemplateIntegerType(value float64, unsigned bool) string {
+	hints := []string{"11", "12", "13", "14", "18"}
+	if unsigned {
+		hints = []string{"21", "22", "23", "24"}
+	}
+	for _, hint := range hints {
+		bits := float64(templateNumberTypes[hint].bytes * 8)
+		minimum, maximum := -math.Pow(2, bits-1), math.Pow(2, bits-1)-1
+		if unsigned {
+			minimum, maximum = 0, math.Pow(2, bits)-1
+		}
+		if value >= minimum && value <= maximum {
+			return hint
+		}
+	}
+	return ""
+}
+
+// roundFloat16 rounds a value to the precision of an IEEE 754 half-precision float
+func roundFloat16(value float64) float64 {
+	if value == 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return value
+	}
+	exponent := math.Floor(math.Log2(math.Abs(value)))
+	exponent = math.Max(exponent, -14)
+	step := math.Pow(2, exponent-10)
+	return math.Round(value/step) * step
+}
+
+// isEmptyNoteValue reports whether a Note value is omitted from templated Notes
+func isEmptyNoteValue(value interface{}) bool {
+	switch value := value.(type) {
+	case bool:
+		return !value
+	case string:
+		return value == ""
+	case json.Number:
+		number, err := value.Float64()
+		return err == nil && number == 0
+	}
+	return false
+}
+
+// sortedKeys returns the keys of a JSON object in alphabetical order
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatTemplateCheck summarizes a template check
+func formatTemplateCheck(result *TemplateCheckResult, hasNote bool) string {
+	var b bytes.Buffer
+	if result.Valid {
+		b.WriteString("Template check passed")
+	} else {
+		fmt.Fprintf(&b, "Template check failed with %d error(s)", len(result.Errors))
+	}
+	fmt.Fprintf(&b, ": %d field(s), %d body byte(s)", len(result.Fields), result.BodyBytes)
+	if result.PayloadBytes > 0 {
+		fmt.Fprintf(&b, " and %d payload byte(s)", result.PayloadBytes)
+	}
+	if result.MetadataBytes > 0 {
+		fmt.Fprintf(&b, " and %d metadata byte(s)", result.MetadataBytes)
+	}
+	fmt.Fprintf(&b, ", about %d byte(s) per Note before compression", result.NoteBytes)
+	for _, field := range result.Fields {
+		if field.Type == "string" && !hasNote {
+			b.WriteString(" (variable-length strings counted as empty; pass a sample Note to size them)")
+			break
+		}
+	}
+	b.WriteString(".")
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckNoteTemplate(t *testing.T) {
+	result, err := CheckNoteTemplate(`{"req":"note.template","file":"readings.qo","body":{"alert":true,"temp":14.1,"count":11,"status":"4"},"length":32}`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.BodyBytes != 10 || result.PayloadBytes != 32 || result.NoteBytes != 72 {
+		t.Errorf("CheckNoteTemplate() = %+v", result)
+	}
+	want := []TemplateField{{"/alert", "bool", 1}, {"/count", "int8", 1}, {"/status", "string(4)", 4}, {"/temp", "float32", 4}}
+	if !reflect.DeepEqual(result.Fields, want) {
+		t.Errorf("CheckNoteTemplate() fields = %+v, want %+v", result.Fields, want)
+	}
+
+	result, err = CheckNoteTemplate(`{"alert":false,"temp":4,"level":4.1,"_time":14.1,"tags":[]}`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	suggestions := map[string]string{}
+	for _, finding := range result.Errors {
+		suggestions[finding.Pointer] = finding.Suggestion
+	}
+	wantSuggestions := map[string]string{"/alert": "true", "/temp": "14", "/level": "14.1", "/_time": "14", "/tags": ""}
+	if result.Valid || !reflect.DeepEqual(suggestions, wantSuggestions) {
+		t.Errorf("CheckNoteTemplate() errors = %+v", result.Errors)
+	}
+}
+
+func TestCheckNoteTemplateNote(t *testing.T) {
+	template := `{"req":"note.template","format":"compact","body":{"count":11,"name":"4","msg":"text","temp":12.1,"levels":[21],"flag":true},"length":4}`
+	note := `{"req":"note.add","body":{"count":300,"name":"toolong","msg":"hello","temp":3.14159,"levels":[1,2,3],"extra":1,"flag":false},"payload":"AQIDBAU="}`
+	result, err := CheckNoteTemplate(template, note)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keywords := map[string]string{}
+	for _, finding := range append(result.Errors, result.Warnings...) {
+		keywords[finding.Pointer] += finding.Keyword + " "
+	}
+	for pointer, want := range map[string]string{
+		"/count":    "range ",
+		"/name":     "truncated ",
+		"/extra":    "dropped ",
+		"/flag":     "omitempty ",
+		"/temp":     "precision ",
+		"/payload":  "payload ",
+		"/levels/0": "firmware ",
+	} {
+		if keywords[pointer] != want {
+			t.Errorf("CheckNoteTemplate() findings for %s = %q, want %q", pointer, keywords[pointer], want)
+		}
+	}
+
+	// The variable-length string is sized by the sample (1 + 5 bytes) and the variable-length array
+	// by its three uint8 elements
+	if result.Valid || result.BodyBytes != 1+1+4+6+2+3 || result.NoteBytes != result.BodyBytes+4 {
+		t.Errorf("CheckNoteTemplate() = %+v", result)
+	}
+}
+
+func TestCheckNoteTemplateEmptyArray(t *testing.T) {
+	result, err := CheckNoteTemplate(`{"a":[]}`, `{"a":[1]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keywords := map[string]string{}
+	for _, finding := range result.Errors {
+		keywords[finding.Pointer] += finding.Keyword + " "
+	}
+	if result.Valid || keywords["/a"] != "type " || keywords["/a/0"] != "dropped " {
+		t.Errorf("CheckNoteTemplate() errors = %+v", result.Errors)
+	}
+}
+
+func TestRoundFloat16(t *testing.T) {
+	for value, want := range map[float64]float64{1.5: 1.5, 3.14159: 3.140625, 1000.3: 1000.5, 0: 0} {
+		if got := roundFloat16(value); got != want {
+			t.Errorf("roundFloat16(%v) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -3,12 +3,16 @@ package lib
 import (
 	"bytes"
 	"context"
+	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,14 +23,6 @@ import (
 	_ "github.com/santhosh-tekuri/jsonschema/v5/httploader" // Enable HTTP/HTTPS loading
 )
 
-// schema is a cached, compiled JSON schema
-var (
-	schema      *jsonschema.Schema
-	schemaOnce  sync.Once
-	schemaErr   error
-	schemaMutex sync.RWMutex
-)
-
 // cacheDir is the directory where schemas are stored
 const cacheDir = "/tmp/notecard-schema/"
 
@@ -36,19 +32,48 @@ const defaultSchemaURL = "https://github.com/blues/notecard-schema/releases/late
 // Cache expiration duration (24 hours)
 const cacheExpirationDuration = 24 * time.Hour
 
+// schemaFetchTimeout bounds each schema download, so that an unreachable network falls back to
+// the bundled snapshot promptly instead of waiting for the TCP timeout
+const schemaFetchTimeout = 15 * time.Second
+
+// schemaHTTPClient is the HTTP client used to fetch schemas
+var schemaHTTPClient = &http.Client{Timeout: schemaFetchTimeout}
+
+// Schema sources recorded in cache metadata
+const (
+	schemaSourceRemote   = "remote"
+	schemaSourceSnapshot = "snapshot"
+	schemaSourceDisk     = "disk"
+)
+
+// schemaSnapshot is the bundled copy of the Notecard API schema, used when GitHub is unreachable
+//
+//go:embed schema
+var schemaSnapshot embed.FS
+
+// offlineMode forces the bundled schema snapshot to be used instead of fetching from GitHub
+var offlineMode bool
+
 // CacheMetadata represents metadata for cached schema files
 type CacheMetadata struct {
 	FetchTime     time.Time `json:"fetch_time"`
 	URL           string    `json:"url"`
 	SchemaVersion string    `json:"schema_version,omitempty"`
+	Source        string    `json:"source,omitempty"`
 }
 
-// resetSchemaWithLock safely resets the schema state for re-initialization
-// This function must be called with schemaMutex write lock held
-func resetSchemaWithLock() {
-	schemaOnce = sync.Once{}
-	schema = nil
-	schemaErr = nil
+// SetOfflineMode enables or disables offline mode. When enabled, the schema is always loaded
+// from the bundled snapshot and no network requests are made.
+func SetOfflineMode(enabled bool) {
+	offlineMode = enabled
+}
+
+// resetWithLock safely resets the schema state for re-initialization
+// This function must be called with the release's write lock held
+func (r *schemaRelease) resetWithLock() {
+	r.once = sync.Once{}
+	r.compiled = nil
+	r.err = nil
 }
 
 // extractRefs recursively extracts $ref URLs from a schema
@@ -74,19 +99,25 @@ func extractRefs(schemaMap map[string]interface{}, baseURL string) []string {
 
 // fetchAndCacheSchema fetches a schema from the URL and caches it
 // If request is provided, it will create or retrieve a session for logging
-func fetchAndCacheSchema(ctx context.Context, request *mcp.CallToolRequest, url string) (io.Reader, error) {
+func (r *schemaRelease) fetchAndCacheSchema(ctx context.Context, request *mcp.CallToolRequest, url string) (io.Reader, error) {
+	// Referenced schemas of a pinned release are fetched from the release tag
+	fetchURL := url
+	if r.refBase != "" && url != r.url {
+		fetchURL = r.refBase + filepath.Base(url)
+	}
+
 	// Log that we're fetching the schema
 	if request != nil && request.Session != nil {
 		request.Session.Log(ctx, &mcp.LoggingMessageParams{
 			Level: "info",
-			Data:  fmt.Sprintf("Fetching Notecard API schema from %s...", url),
+			Data:  fmt.Sprintf("Fetching Notecard API schema from %s...", fetchURL),
 		})
 	}
-	log.Debug().Str("url", url).Msg("Fetching Notecard API schema")
+	log.Debug().Str("url", fetchURL).Msg("Fetching Notecard API schema")
 
-	resp, err := http.Get(url)
+	resp, err := schemaHTTPClient.Get(fetchURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch schema %s: %v", url, err)
+		return nil, fmt.Errorf("failed to fetch schema %s: %v", fetchURL, err)
 	}
 	defer resp.Body.Close()
 
@@ -100,7 +131,7 @@ func fetchAndCacheSchema(ctx context.Context, request *mcp.CallToolRequest, url
 	log.Info().Msg("Schema download in progress, please wait...")
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch schema %s: status %d", url, resp.StatusCode)
+		return nil, fmt.Errorf("failed to fetch schema %s: status %d", fetchURL, resp.StatusCode)
 	}
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -138,14 +169,14 @@ func fetchAndCacheSchema(ctx context.Context, request *mcp.CallToolRequest, url
 	log.Debug().Msg("Caching schema for future use...")
 
 	// Save to cache
-	cachePath := getCachePath(url)
+	cachePath := r.getCachePath(url)
 	fetchTime := time.Now()
 	if err := os.WriteFile(cachePath, data, 0600); err != nil {
 		// Log error but continue - don't fail if we can't cache
 		log.Warn().Str("url", url).Err(err).Msg("Failed to cache schema")
 	} else {
 		// Save cache metadata with schema version
-		if err := saveCacheMetadata(url, fetchTime, schemaVersion); err != nil {
+		if err := r.saveCacheMetadata(url, fetchTime, schemaVersion, schemaSourceRemote); err != nil {
 			log.Warn().Str("url", url).Err(err).Msg("Failed to save cache metadata")
 		}
 	}
@@ -163,89 +194,219 @@ func fetchAndCacheSchema(ctx context.Context, request *mcp.CallToolRequest, url
 }
 
 // fetchAndCacheSchemaBackground fetches a schema without MCP logging (for background operations)
-func fetchAndCacheSchemaBackground(url string) (io.Reader, error) {
-	return fetchAndCacheSchema(context.Background(), nil, url)
+func (r *schemaRelease) fetchAndCacheSchemaBackground(url string) (io.Reader, error) {
+	return r.fetchAndCacheSchema(context.Background(), nil, url)
 }
 
-// formatErrorMessage formats jsonschema validation errors into user-friendly messages
-func formatErrorMessage(reqType string, errUnformatted error) (err error) {
-	if errUnformatted == nil {
-		return nil
+// fetchWithSnapshotFallback fetches a schema, falling back to the bundled snapshot if the fetch fails
+func (r *schemaRelease) fetchWithSnapshotFallback(url string) (io.Reader, error) {
+	reader, err := r.fetchAndCacheSchemaBackground(url)
+	if err == nil {
+		return reader, nil
 	}
 
-	// Convert the error to a string
-	errMsg := errUnformatted.Error()
+	log.Warn().Str("url", url).Err(err).Msg("Failed to fetch schema, falling back to bundled snapshot")
+	reader, snapshotErr := r.loadSnapshotSchema(url)
+	if snapshotErr != nil {
+		return nil, fmt.Errorf("%v (bundled snapshot fallback failed: %v)", err, snapshotErr)
+	}
+	return reader, nil
+}
+
+// loadSnapshotSchema loads a schema from the bundled snapshot and caches it
+func (r *schemaRelease) loadSnapshotSchema(url string) (io.Reader, error) {
+	if r.version != "" && r.version != bundledSchemaVersion() {
+		return nil, fmt.Errorf("schema version %s is not in the bundled snapshot", r.version)
+	}
 
-	// Define constants
-	const prefix = "jsonschema: '"
-	const mid1 = "' does not validate with "
-	const mid2 = ": "
+	filename := filepath.Base(url)
+	data, err := schemaSnapshot.ReadFile("schema/" + filename)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s not found in bundled snapshot: %v", filename, err)
+	}
 
-	// Check if message starts with prefix
-	if !strings.HasPrefix(errMsg, prefix) {
-		return fmt.Errorf("invalid error message format")
+	// Extract schema version from the snapshot if available
+	var schemaMap map[string]interface{}
+	if err := json.Unmarshal(data, &schemaMap); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema in bundled snapshot %s: %v", filename, err)
+	}
+	var schemaVersion string
+	if version, ok := schemaMap["version"].(string); ok {
+		schemaVersion = version
 	}
 
-	// Remove prefix and split on mid1
-	rest := strings.TrimPrefix(errMsg, prefix)
-	parts := strings.SplitN(rest, mid1, 2)
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid error message format")
+	// Cache the snapshot so that per-request schemas can be read from the cache directory
+	cachePath := r.getCachePath(url)
+	if err := os.WriteFile(cachePath, data, 0600); err != nil {
+		log.Warn().Str("url", url).Err(err).Msg("Failed to cache snapshot schema")
+	} else if err := r.saveCacheMetadata(url, time.Now(), schemaVersion, schemaSourceSnapshot); err != nil {
+		log.Warn().Str("url", url).Err(err).Msg("Failed to save cache metadata")
 	}
 
-	// Extract property and remaining part
-	property := parts[0]
-	if len(property) > 0 {
-		// As of jsonschema v5.3.1, a forward-slash is prefixed to the
-		// property name. Remove it to improve readability.
-		// Workaround for issue:
-		// https://github.com/santhosh-tekuri/jsonschema/issues/220
-		property = parts[0][1:]
+	log.Debug().Str("file", filename).Msg("Loaded schema from bundled snapshot")
+	return bytes.NewReader(data), nil
+}
+
+// loadDiskSchema loads a schema of a release stored on disk
+func (r *schemaRelease) loadDiskSchema(url string) (io.Reader, error) {
+	path := filepath.Join(r.diskDir, filepath.Base(url))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema %s: %v", path, err)
 	}
-	remaining := parts[1]
+	return bytes.NewReader(data), nil
+}
 
-	// Split remaining part on mid2
-	finalParts := strings.SplitN(remaining, mid2, 2)
-	if len(finalParts) != 2 {
-		return fmt.Errorf("invalid error message format")
+// ValidationFinding describes a single schema violation found in a Notecard request
+type ValidationFinding struct {
+	Pointer    string      `json:"pointer"`
+	Value      interface{} `json:"value,omitempty"`
+	Keyword    string      `json:"keyword"`
+	Message    string      `json:"message"`
+	Suggestion string      `json:"suggestion,omitempty"`
+}
+
+// RequestValidationError is returned when a request does not conform to its request schema.
+// It holds every violation found, not just the first.
+type RequestValidationError struct {
+	ReqType  string              `json:"request"`
+	Findings []ValidationFinding `json:"findings"`
+}
+
+// Error formats the findings into a single user-friendly message
+func (e *RequestValidationError) Error() string {
+	messages := make([]string, 0, len(e.Findings))
+	for _, finding := range e.Findings {
+		// Drop the leading slash of the JSON pointer to improve readability
+		property := strings.TrimPrefix(finding.Pointer, "/")
+		if finding.Keyword == "additionalProperties" || finding.Keyword == "skus" || finding.Keyword == "apiVersion" {
+			// Unknown property and target compatibility messages already name the property and request
+			messages = append(messages, finding.Message)
+		} else if property != "" {
+			messages = append(messages, fmt.Sprintf("'%s' is not valid for %s: %s", property, e.ReqType, finding.Message))
+		} else {
+			messages = append(messages, fmt.Sprintf("for '%s' %s", e.ReqType, finding.Message))
+		}
 	}
+	return strings.Join(messages, "; ")
+}
 
-	// Extract schema rule and error message
-	// schemaRule := finalParts[0] // Not used in output, but available if needed
-	errorMessage := finalParts[1]
+// collectFindings walks a jsonschema validation error tree and returns a finding for each leaf error
+func collectFindings(ve *jsonschema.ValidationError, instance interface{}) []ValidationFinding {
+	keyword := schemaKeyword(ve.KeywordLocation)
 
-	if len(property) > 0 {
-		err = fmt.Errorf("'%s' is not valid for %s: %s", property, reqType, errorMessage)
-	} else {
-		err = fmt.Errorf("for '%s' %s", reqType, errorMessage)
+	// The causes of a oneOf/anyOf failure describe every alternative, so report the failure as a whole
+	if len(ve.Causes) == 0 || keyword == "oneOf" || keyword == "anyOf" {
+		finding := ValidationFinding{
+			Pointer: ve.InstanceLocation,
+			Keyword: keyword,
+			Message: ve.Message,
+		}
+		if ve.InstanceLocation != "" {
+			finding.Value = lookupJSONPointer(instance, ve.InstanceLocation)
+		}
+		return []ValidationFinding{finding}
 	}
 
-	// Return the formatted error
-	return err
+	var findings []ValidationFinding
+	for _, cause := range ve.Causes {
+		findings = append(findings, collectFindings(cause, instance)...)
+	}
+	return findings
+}
+
+// expandUnknownProperties replaces request-level additionalProperties findings with one finding
+// per unknown property, each with a suggestion for the closest known property name
+func expandUnknownProperties(reqType string, findings []ValidationFinding, reqMap map[string]interface{}, knownProps []string) []ValidationFinding {
+	known := make(map[string]bool, len(knownProps))
+	for _, name := range knownProps {
+		known[name] = true
+	}
+
+	expanded := make([]ValidationFinding, 0, len(findings))
+	for _, finding := range findings {
+		if finding.Keyword != "additionalProperties" || finding.Pointer != "" || len(knownProps) == 0 {
+			expanded = append(expanded, finding)
+			continue
+		}
+
+		for name, value := range reqMap {
+			if name == "req" || name == "cmd" || known[name] {
+				continue
+			}
+			unknown := ValidationFinding{
+				Pointer: "/" + strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1"),
+				Value:   value,
+				Keyword: "additionalProperties",
+				Message: fmt.Sprintf("unknown property '%s' on %s", name, reqType),
+			}
+			if suggestion := suggestClosest(name, knownProps); suggestion != "" {
+				unknown.Suggestion = suggestion
+				unknown.Message += fmt.Sprintf(" — did you mean '%s'?", suggestion)
+			}
+			expanded = append(expanded, unknown)
+		}
+	}
+
+	return expanded
+}
+
+// schemaKeyword returns the schema keyword at the end of a keyword location (e.g. "/properties/minutes/type" -> "type")
+func schemaKeyword(keywordLocation string) string {
+	if i := strings.LastIndex(keywordLocation, "/"); i >= 0 {
+		return keywordLocation[i+1:]
+	}
+	return keywordLocation
+}
+
+// lookupJSONPointer returns the value at a JSON pointer within a decoded JSON document, or nil if not found
+func lookupJSONPointer(document interface{}, pointer string) interface{} {
+	if pointer == "" {
+		return document
+	}
+
+	current := document
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := current.(type) {
+		case map[string]interface{}:
+			current = v[token]
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			current = v[index]
+		default:
+			return nil
+		}
+	}
+	return current
 }
 
-// getCachePath converts a URL to a safe file path in the cache directory
-func getCachePath(url string) string {
+// getCachePath converts a URL to a safe file path in the release's cache directory
+func (r *schemaRelease) getCachePath(url string) string {
 	// Use the URL path as the filename, replacing invalid characters
 	filename := strings.ReplaceAll(filepath.Base(url), string(os.PathSeparator), "_")
-	return filepath.Join(cacheDir, filename)
+	return filepath.Join(r.dir, filename)
 }
 
 // getCacheMetadataPath returns the metadata file path for a cached schema
-func getCacheMetadataPath(url string) string {
-	cachePath := getCachePath(url)
+func (r *schemaRelease) getCacheMetadataPath(url string) string {
+	cachePath := r.getCachePath(url)
 	return cachePath + ".meta"
 }
 
 // saveCacheMetadata saves metadata for a cached schema file
-func saveCacheMetadata(url string, fetchTime time.Time, schemaVersion string) error {
+func (r *schemaRelease) saveCacheMetadata(url string, fetchTime time.Time, schemaVersion string, source string) error {
 	metadata := CacheMetadata{
 		FetchTime:     fetchTime,
 		URL:           url,
 		SchemaVersion: schemaVersion,
+		Source:        source,
 	}
 
-	metadataPath := getCacheMetadataPath(url)
+	metadataPath := r.getCacheMetadataPath(url)
 	data, err := json.Marshal(metadata)
 	if err != nil {
 		return fmt.Errorf("failed to marshal cache metadata: %v", err)
@@ -259,8 +420,8 @@ func saveCacheMetadata(url string, fetchTime time.Time, schemaVersion string) er
 }
 
 // loadCacheMetadata loads metadata for a cached schema file
-func loadCacheMetadata(url string) (*CacheMetadata, error) {
-	metadataPath := getCacheMetadataPath(url)
+func (r *schemaRelease) loadCacheMetadata(url string) (*CacheMetadata, error) {
+	metadataPath := r.getCacheMetadataPath(url)
 
 	data, err := os.ReadFile(metadataPath)
 	if err != nil {
@@ -276,13 +437,23 @@ func loadCacheMetadata(url string) (*CacheMetadata, error) {
 }
 
 // isCacheExpired checks if a cached schema has expired
-func isCacheExpired(url string) bool {
-	metadata, err := loadCacheMetadata(url)
+func (r *schemaRelease) isCacheExpired(url string) bool {
+	metadata, err := r.loadCacheMetadata(url)
 	if err != nil {
 		// If we can't load metadata, consider it expired to force refresh
 		return true
 	}
 
+	// A snapshot copy is only a stand-in, so try the remote schema again when online
+	if metadata.Source == schemaSourceSnapshot && !offlineMode {
+		return true
+	}
+
+	// Tagged releases never change once published
+	if r.version != "" {
+		return false
+	}
+
 	return time.Since(metadata.FetchTime) > cacheExpirationDuration
 }
 
@@ -292,7 +463,12 @@ func GetSchemaVersion(schemaURL string) string {
 		schemaURL = defaultSchemaURL
 	}
 
-	metadata, err := loadCacheMetadata(schemaURL)
+	release := schemaReleaseForURL(schemaURL)
+	if release.diskDir != "" {
+		return release.version
+	}
+
+	metadata, err := release.loadCacheMetadata(schemaURL)
 	if err != nil {
 		return "unknown"
 	}
@@ -304,12 +480,38 @@ func GetSchemaVersion(schemaURL string) string {
 	return metadata.SchemaVersion
 }
 
-// initSchema compiles the schema, using cached files if available
+// GetSchemaSource reports whether the schema came from the remote URL, the bundled snapshot or disk
+func GetSchemaSource(schemaURL string) string {
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+
+	release := schemaReleaseForURL(schemaURL)
+	if release.diskDir != "" {
+		return schemaSourceDisk
+	}
+
+	metadata, err := release.loadCacheMetadata(schemaURL)
+	if err != nil || metadata.Source == "" {
+		return schemaSourceRemote
+	}
+
+	return metadata.Source
+}
+
+// initSchema compiles the schema for a main schema URL, using cached files if available
 func initSchema(url string) error {
-	schemaMutex.RLock()
-	currentSchema := schema
-	currentErr := schemaErr
-	schemaMutex.RUnlock()
+	return schemaReleaseForURL(url).init()
+}
+
+// init compiles the release's schema, using cached files if available
+func (r *schemaRelease) init() error {
+	url := r.url
+
+	r.mutex.RLock()
+	currentSchema := r.compiled
+	currentErr := r.err
+	r.mutex.RUnlock()
 
 	// If schema is already initialized and no error, return early
 	if currentSchema != nil && currentErr == nil {
@@ -317,38 +519,40 @@ func initSchema(url string) error {
 	}
 
 	// Use sync.Once for initialization, but protect the state with mutex
-	schemaOnce.Do(func() {
-		schemaMutex.Lock()
-		defer schemaMutex.Unlock()
+	r.once.Do(func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
 
 		compiler := jsonschema.NewCompiler()
 		compiler.Draft = jsonschema.Draft2020
 
 		// Ensure cache directory exists
-		if err := os.MkdirAll(cacheDir, 0700); err != nil {
-			schemaErr = fmt.Errorf("failed to create cache directory %s: %v", cacheDir, err)
-			return
+		if r.diskDir == "" {
+			if err := os.MkdirAll(r.dir, 0700); err != nil {
+				r.err = fmt.Errorf("failed to create cache directory %s: %v", r.dir, err)
+				return
+			}
 		}
 
-		mainSchemaReader, err := loadOrFetchSchema(url)
+		mainSchemaReader, err := r.loadOrFetchSchema(url)
 		if err != nil {
-			schemaErr = fmt.Errorf("failed to load main schema %s: %v", url, err)
+			r.err = fmt.Errorf("failed to load main schema %s: %v", url, err)
 			return
 		}
 		// Read main schema to extract $ref URLs
 		mainSchemaData, err := io.ReadAll(mainSchemaReader)
 		if err != nil {
-			schemaErr = fmt.Errorf("failed to read main schema %s: %v", url, err)
+			r.err = fmt.Errorf("failed to read main schema %s: %v", url, err)
 			return
 		}
 		var mainSchema map[string]interface{}
 		if err := json.Unmarshal(mainSchemaData, &mainSchema); err != nil {
-			schemaErr = fmt.Errorf("failed to parse main schema %s: %v", url, err)
+			r.err = fmt.Errorf("failed to parse main schema %s: %v", url, err)
 			return
 		}
 		// Add main schema resource
 		if err := compiler.AddResource(url, bytes.NewReader(mainSchemaData)); err != nil {
-			schemaErr = fmt.Errorf("failed to add main schema resource %s: %v", url, err)
+			r.err = fmt.Errorf("failed to add main schema resource %s: %v", url, err)
 			return
 		}
 		// Extract and cache referenced schemas
@@ -356,47 +560,61 @@ func initSchema(url string) error {
 		if len(refs) > 0 {
 			log.Debug().Int("count", len(refs)).Msg("Processing referenced schema files")
 		}
+		// If the main schema came from the bundled snapshot, don't try the network for each reference
+		useSnapshot := r.diskDir == "" && (offlineMode || GetSchemaSource(url) == schemaSourceSnapshot)
 		for i, refURL := range refs {
 			log.Debug().
 				Int("current", i+1).
 				Int("total", len(refs)).
 				Str("file", filepath.Base(refURL)).
 				Msg("Loading referenced schema")
-			refReader, err := loadOrFetchSchema(refURL)
+			var refReader io.Reader
+			if useSnapshot {
+				refReader, err = r.loadSnapshotSchema(refURL)
+			} else {
+				refReader, err = r.loadOrFetchSchema(refURL)
+			}
 			if err != nil {
-				schemaErr = fmt.Errorf("failed to load referenced schema %s: %v", refURL, err)
+				r.err = fmt.Errorf("failed to load referenced schema %s: %v", refURL, err)
 				return
 			}
 			if err := compiler.AddResource(refURL, refReader); err != nil {
-				schemaErr = fmt.Errorf("failed to add referenced schema resource %s: %v", refURL, err)
+				r.err = fmt.Errorf("failed to add referenced schema resource %s: %v", refURL, err)
 				return
 			}
 		}
 
-		schema, err = compiler.Compile(url)
+		r.compiled, err = compiler.Compile(url)
 		if err != nil {
-			schemaErr = fmt.Errorf("failed to compile schema %s: %v", url, err)
+			r.err = fmt.Errorf("failed to compile schema %s: %v", url, err)
 			return
 		}
 	})
 
-	schemaMutex.RLock()
-	defer schemaMutex.RUnlock()
-	return schemaErr
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.err
 }
 
 // loadOrFetchSchema loads a schema from cache or fetches it from the URL, caching the result
-func loadOrFetchSchema(url string) (io.Reader, error) {
-	cachePath := getCachePath(url)
+func (r *schemaRelease) loadOrFetchSchema(url string) (io.Reader, error) {
+	if r.diskDir != "" {
+		return r.loadDiskSchema(url)
+	}
+	if offlineMode {
+		return r.loadSnapshotSchema(url)
+	}
+
+	cachePath := r.getCachePath(url)
 
 	// Check if cache exists and is not expired
 	if file, err := os.Open(cachePath); err == nil {
 		defer file.Close()
 
 		// Check if cache has expired
-		if isCacheExpired(url) {
+		if r.isCacheExpired(url) {
 			// Cache expired: fetch fresh copy
-			return fetchAndCacheSchemaBackground(url)
+			return r.fetchWithSnapshotFallback(url)
 		}
 
 		data, err := io.ReadAll(file)
@@ -407,16 +625,16 @@ func loadOrFetchSchema(url string) (io.Reader, error) {
 		var v interface{}
 		if err := json.Unmarshal(data, &v); err != nil {
 			// Invalid cache: proceed to fetch
-			return fetchAndCacheSchemaBackground(url)
+			return r.fetchWithSnapshotFallback(url)
 		}
 		return bytes.NewReader(data), nil
 	}
 	// Cache miss: fetch from URL
-	return fetchAndCacheSchemaBackground(url)
+	return r.fetchWithSnapshotFallback(url)
 }
 
 // resolveSchemaError attempts to validate against specific request schemas for better error messages
-func resolveSchemaError(reqMap map[string]interface{}) (err error) {
+func (r *schemaRelease) resolveSchemaError(reqMap map[string]interface{}) (err error) {
 	reqType := reqMap["req"]
 	if reqType == nil {
 		reqType = reqMap["cmd"]
@@ -428,16 +646,29 @@ func resolveSchemaError(reqMap map[string]interface{}) (err error) {
 		err = fmt.Errorf("no request type specified")
 	} else {
 		// Validate against the specific request schema
-		schemaPath := filepath.Join(cacheDir, reqTypeStr+".req.notecard.api.json")
+		schemaPath := r.requestSchemaPath(reqTypeStr)
 		if _, err = os.Stat(schemaPath); os.IsNotExist(err) {
-			err = fmt.Errorf("unknown request type: %s", reqTypeStr)
+			if suggestion := suggestClosest(reqTypeStr, cachedAPINames(r.dir)); suggestion != "" {
+				err = fmt.Errorf("unknown request '%s' — did you mean '%s'?", reqTypeStr, suggestion)
+			} else {
+				err = fmt.Errorf("unknown request type: %s", reqTypeStr)
+			}
 		} else if err == nil {
 			var reqSchema *jsonschema.Schema
 			reqSchema, err = jsonschema.Compile(schemaPath)
 			if err == nil {
 				err = reqSchema.Validate(reqMap)
-				if err != nil {
-					err = formatErrorMessage(reqTypeStr, err)
+				var ve *jsonschema.ValidationError
+				if errors.As(err, &ve) {
+					findings := expandUnknownProperties(reqTypeStr, collectFindings(ve, reqMap), reqMap, requestSchemaProperties(schemaPath))
+					// Keep findings in a stable order, as schema properties are validated in map order
+					sort.SliceStable(findings, func(i, j int) bool {
+						return findings[i].Pointer < findings[j].Pointer
+					})
+					err = &RequestValidationError{
+						ReqType:  reqTypeStr,
+						Findings: findings,
+					}
 				}
 			}
 		}
@@ -448,30 +679,218 @@ func resolveSchemaError(reqMap map[string]interface{}) (err error) {
 
 // ValidateNotecardRequest validates a Notecard API request against the schema
 func ValidateNotecardRequest(reqMap map[string]interface{}, schemaURL string) error {
+	return ValidateNotecardRequestForTarget(reqMap, schemaURL, ValidationTarget{})
+}
+
+// ValidateNotecardRequestForTarget validates a Notecard API request against the schema and, when a
+// target is given, checks that the request, its properties and their values are supported by the
+// target Notecard SKU and firmware version
+func ValidateNotecardRequestForTarget(reqMap map[string]interface{}, schemaURL string, target ValidationTarget) error {
 	if schemaURL == "" {
 		schemaURL = defaultSchemaURL
 	}
 
+	resolved, err := resolveTarget(target)
+	if err != nil {
+		return err
+	}
+
 	if err := initSchema(schemaURL); err != nil {
 		return fmt.Errorf("failed to initialize schema: %v", err)
 	}
 
 	// Use read lock to safely access schema for validation
-	schemaMutex.RLock()
-	currentSchema := schema
-	schemaMutex.RUnlock()
+	release := schemaReleaseForURL(schemaURL)
+	release.mutex.RLock()
+	currentSchema := release.compiled
+	release.mutex.RUnlock()
 
 	if currentSchema == nil {
 		return fmt.Errorf("schema not initialized")
 	}
 
 	if err := currentSchema.Validate(reqMap); err != nil {
-		return resolveSchemaError(reqMap)
+		err = release.resolveSchemaError(reqMap)
+		var validationErr *RequestValidationError
+		if target.IsZero() || !errors.As(err, &validationErr) {
+			return err
+		}
+		// Report target incompatibilities alongside the schema violations
+		validationErr.Findings = append(validationErr.Findings, release.checkTargetCompatibility(validationErr.ReqType, reqMap, resolved)...)
+		sort.SliceStable(validationErr.Findings, func(i, j int) bool {
+			return validationErr.Findings[i].Pointer < validationErr.Findings[j].Pointer
+		})
+		return validationErr
+	}
+
+	if !target.IsZero() {
+		reqType, _ := reqMap["req"].(string)
+		if reqType == "" {
+			reqType, _ = reqMap["cmd"].(string)
+		}
+		if findings := release.checkTargetCompatibility(reqType, reqMap, resolved); len(findings) > 0 {
+			return &RequestValidationError{
+				ReqType:  reqType,
+				Findings: findings,
+			}
+		}
 	}
 
 	return nil
 }
 
+// BatchItemResult holds the validation outcome of a single request in a batch
+type BatchItemResult struct {
+	Index    int                 `json:"index"`
+	Line     int                 `json:"line,omitempty"`
+	Request  string              `json:"request,omitempty"`
+	Valid    bool                `json:"valid"`
+	Error    string              `json:"error,omitempty"`
+	Findings []ValidationFinding `json:"findings,omitempty"`
+}
+
+// BatchValidationResult holds the per-request results and summary of a batch validation
+type BatchValidationResult struct {
+	Total   int               `json:"total"`
+	Valid   int               `json:"valid"`
+	Invalid int               `json:"invalid"`
+	Results []BatchItemResult `json:"results,omitempty"`
+}
+
+// batchItem is a single raw request split from a batch input
+type batchItem struct {
+	line int
+	data json.RawMessage
+}
+
+// splitNotecardRequests splits a JSON array, or a sequence of JSON requests such as newline-delimited
+// JSON, concatenated or pretty-printed objects, into individual raw requests. In a sequence, text that
+// is not valid JSON is returned as an item of its own up to the end of its line, so that the requests
+// after it are still split.
+func splitNotecardRequests(input string) ([]batchItem, error) {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" {
+		return nil, fmt.Errorf("no requests provided")
+	}
+
+	// JSON array of requests
+	if strings.HasPrefix(trimmed, "[") {
+		var raw []json.RawMessage
+		if err := json.Unmarshal([]byte(trimmed), &raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %v", err)
+		}
+		if len(raw) == 0 {
+			return nil, fmt.Errorf("no requests provided")
+		}
+		items := make([]batchItem, 0, len(raw))
+		for _, data := range raw {
+			items = append(items, batchItem{data: data})
+		}
+		return items, nil
+	}
+
+	// Sequence of JSON values, each reported with the line it starts on
+	var items []batchItem
+	offset := 0
+	for offset < len(trimmed) {
+		rest := trimmed[offset:]
+		start := offset + len(rest) - len(strings.TrimLeft(rest, " \t\r\n"))
+		if start == len(trimmed) {
+			break
+		}
+		line := strings.Count(trimmed[:start], "\n") + 1
+
+		decoder := json.NewDecoder(strings.NewReader(trimmed[start:]))
+		var data json.RawMessage
+		if err := decoder.Decode(&data); err != nil {
+			end := strings.IndexByte(trimmed[start:], '\n')
+			if end < 0 {
+				end = len(trimmed) - start
+			}
+			items = append(items, batchItem{line: line, data: json.RawMessage(strings.TrimSpace(trimmed[start : start+end]))})
+			offset = start + end
+			continue
+		}
+		items = append(items, batchItem{line: line, data: data})
+		offset = start + int(decoder.InputOffset())
+	}
+	return items, nil
+}
+
+// validateBatchItem validates a single decoded request and records the outcome in the item result
+func validateBatchItem(itemResult *BatchItemResult, reqMap map[string]interface{}, schemaURL string) {
+	if reqType, ok := reqMap["req"].(string); ok {
+		itemResult.Request = reqType
+	} else if cmdType, ok := reqMap["cmd"].(string); ok {
+		itemResult.Request = cmdType
+	}
+
+	if err := ValidateNotecardRequest(reqMap, schemaURL); err != nil {
+		itemResult.Error = err.Error()
+		var validationErr *RequestValidationError
+		if errors.As(err, &validationErr) {
+			itemResult.Findings = validationErr.Findings
+		}
+		return
+	}
+
+	itemResult.Valid = true
+}
+
+// validateRawRequest decodes and validates a single raw request, recording the outcome in the item
+// result. The decoded request is returned, or nil if it is not a JSON object.
+func validateRawRequest(itemResult *BatchItemResult, data json.RawMessage, schemaURL string) map[string]interface{} {
+	var reqMap map[string]interface{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] != '{' && json.Valid(trimmed) {
+		itemResult.Error = "request must be a JSON object"
+		return nil
+	} else if err := json.Unmarshal(data, &reqMap); err != nil {
+		itemResult.Error = fmt.Sprintf("invalid JSON request: %v", err)
+		return nil
+	}
+	validateBatchItem(itemResult, reqMap, schemaURL)
+	return reqMap
+}
+
+// ValidateNotecardRequestBatch validates many Notecard API requests at once. The input may be a JSON
+// array of requests or a sequence of requests, such as newline-delimited JSON. An error is only
+// returned if the input as a whole cannot be processed; invalid requests are reported in the per-item
+// results.
+func ValidateNotecardRequestBatch(input string, schemaURL string) (*BatchValidationResult, error) {
+	items, err := splitNotecardRequests(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+	if err := initSchema(schemaURL); err != nil {
+		return nil, fmt.Errorf("failed to initialize schema: %v", err)
+	}
+
+	result := &BatchValidationResult{
+		Total:   len(items),
+		Results: make([]BatchItemResult, 0, len(items)),
+	}
+	for i, item := range items {
+		itemResult := BatchItemResult{
+			Index: i,
+			Line:  item.line,
+		}
+
+		validateRawRequest(&itemResult, item.data, schemaURL)
+		if itemResult.Valid {
+			result.Valid++
+		} else {
+			result.Invalid++
+		}
+		result.Results = append(result.Results, itemResult)
+	}
+
+	return result, nil
+}
+
 // APICategory represents a category of Notecard APIs
 type APICategory struct {
 	Name        string     `json:"name"`
@@ -509,6 +928,7 @@ type APIProperty struct {
 	Minimum         *float64                 `json:"minimum,omitempty"`
 	Maximum         *float64                 `json:"maximum,omitempty"`
 	SKUs            []string                 `json:"skus,omitempty"`
+	APIVersion      string                   `json:"api_version,omitempty"`
 	SubDescriptions []PropertySubDescription `json:"sub_descriptions,omitempty"`
 }
 
@@ -517,17 +937,23 @@ type PropertySubDescription struct {
 	Const       string   `json:"const"`
 	Description string   `json:"description"`
 	SKUs        []string `json:"skus,omitempty"`
+	APIVersion  string   `json:"api_version,omitempty"`
 }
 
 // GetNotecardAPIs returns API documentation for a specific API or lists available APIs
-func GetNotecardAPIs(ctx context.Context, request *mcp.CallToolRequest, apiName string) (*APICategory, error) {
+func GetNotecardAPIs(ctx context.Context, request *mcp.CallToolRequest, apiName string, schemaURL string) (*APICategory, error) {
+	if schemaURL == "" {
+		schemaURL = defaultSchemaURL
+	}
+	release := schemaReleaseForURL(schemaURL)
+
 	// Ensure schema is initialized
-	if err := initSchema(defaultSchemaURL); err != nil {
+	if err := release.init(); err != nil {
 		return nil, fmt.Errorf("failed to initialize schema: %v", err)
 	}
 
 	// Load cached schema files to extract API documentation
-	cacheFiles, err := filepath.Glob(filepath.Join(cacheDir, "*.req.notecard.api.json"))
+	cacheFiles, err := filepath.Glob(filepath.Join(release.dir, "*.req.notecard.api.json"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to find cached schema files: %v", err)
 	}
@@ -544,17 +970,17 @@ func GetNotecardAPIs(ctx context.Context, request *mcp.CallToolRequest, apiName
 		log.Debug().Msg("No cached API schema found, fetching fresh schema from remote...")
 
 		// Force a fresh fetch by safely resetting the schema cache
-		schemaMutex.Lock()
-		resetSchemaWithLock()
-		schemaMutex.Unlock()
+		release.mutex.Lock()
+		release.resetWithLock()
+		release.mutex.Unlock()
 
 		// Re-initialize schema which will populate the cache
-		if err := initSchema(defaultSchemaURL); err != nil {
+		if err := release.init(); err != nil {
 			return nil, fmt.Errorf("failed to fetch and initialize schema: %v", err)
 		}
 
 		// Try to find cache files again after initialization
-		cacheFiles, err = filepath.Glob(filepath.Join(cacheDir, "*.req.notecard.api.json"))
+		cacheFiles, err = filepath.Glob(filepath.Join(release.dir, "*.req.notecard.api.json"))
 		if err != nil {
 			return nil, fmt.Errorf("failed to find cached schema files after initialization: %v", err)
 		}
@@ -566,7 +992,7 @@ func GetNotecardAPIs(ctx context.Context, request *mcp.CallToolRequest, apiName
 
 	// If specific API requested, find and return just that API
 	if apiName != "" {
-		schemaFile := filepath.Join(cacheDir, apiName+".req.notecard.api.json")
+		schemaFile := release.requestSchemaPath(apiName)
 
 		// Check if the specific API schema file exists
 		if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
@@ -580,16 +1006,19 @@ func GetNotecardAPIs(ctx context.Context, request *mcp.CallToolRequest, apiName
 			log.Debug().Str("api", apiName).Msg("API not found in cache, refreshing schema...")
 
 			// Try to refresh the cache in case the API was recently added
-			schemaMutex.Lock()
-			resetSchemaWithLock()
-			schemaMutex.Unlock()
+			release.mutex.Lock()
+			release.resetWithLock()
+			release.mutex.Unlock()
 
-			if err := initSchema(defaultSchemaURL); err != nil {
+			if err := release.init(); err != nil {
 				return nil, fmt.Errorf("failed to refresh schema for API '%s': %v", apiName, err)
 			}
 
 			// Check again after refresh
 			if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+				if suggestion := suggestClosest(apiName, cachedAPINames(release.dir)); suggestion != "" {
+					return nil, fmt.Errorf("API '%s' not found — did you mean '%s'? Available APIs can be listed by calling this tool without the 'api' parameter", apiName, suggestion)
+				}
 				return nil, fmt.Errorf("API '%s' not found. Available APIs can be listed by calling this tool without the 'api' parameter", apiName)
 			}
 		}
@@ -752,6 +1181,9 @@ func extractAPIFromSchema(apiName string, schemaData map[string]interface{}) *AP
 						}
 					}
 				}
+				if propAPIVersion, ok := propMap["apiVersion"].(string); ok {
+					property.APIVersion = propAPIVersion
+				}
 				if subDescs, ok := propMap["sub-descriptions"].([]interface{}); ok {
 					for _, subDescInterface := range subDescs {
 						if subDescMap, ok := subDescInterface.(map[string]interface{}); ok {
@@ -770,6 +1202,9 @@ func extractAPIFromSchema(apiName string, schemaData map[string]interface{}) *AP
 									}
 								}
 							}
+							if subDescAPIVersion, ok := subDescMap["apiVersion"].(string); ok {
+								subDesc.APIVersion = subDescAPIVersion
+							}
 
 							property.SubDescriptions = append(property.SubDescriptions, subDesc)
 						}
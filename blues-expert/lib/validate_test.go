@@ -0,0 +1,78 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSplitNotecardRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantLines []int
+		wantData  []string
+	}{
+		{
+			name:      "array",
+			input:     `[{"req":"card.version"}, {"req":"hub.get"}]`,
+			wantLines: []int{0, 0},
+			wantData:  []string{`{"req":"card.version"}`, `{"req":"hub.get"}`},
+		},
+		{
+			name:      "newline-delimited",
+			input:     "{\"req\":\"card.version\"}\n\n{\"req\":\"hub.get\"}",
+			wantLines: []int{1, 3},
+			wantData:  []string{`{"req":"card.version"}`, `{"req":"hub.get"}`},
+		},
+		{
+			name:      "pretty-printed",
+			input:     "{\n  \"req\": \"card.version\"\n}\n{\n  \"req\": \"hub.set\",\n  \"mode\": \"periodic\"\n}",
+			wantLines: []int{1, 4},
+			wantData:  []string{`{"req":"card.version"}`, `{"req":"hub.set","mode":"periodic"}`},
+		},
+		{
+			name:      "concatenated",
+			input:     `{"req":"card.version"}{"req":"hub.get"}`,
+			wantLines: []int{1, 1},
+			wantData:  []string{`{"req":"card.version"}`, `{"req":"hub.get"}`},
+		},
+		{
+			name:      "invalid line is kept on its own",
+			input:     "{\"req\":\"card.version\"\n{\"req\":\"hub.get\"}",
+			wantLines: []int{1, 2},
+			wantData:  []string{`{"req":"card.version"`, `{"req":"hub.get"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := splitNotecardRequests(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != len(tt.wantData) {
+				t.Fatalf("splitNotecardRequests() returned %d items, want %d", len(items), len(tt.wantData))
+			}
+			for i, item := range items {
+				if item.line != tt.wantLines[i] || compactJSON(item.data) != tt.wantData[i] {
+					t.Errorf("splitNotecardRequests() item %d = line %d %s, want line %d %s", i, item.line, item.data, tt.wantLines[i], tt.wantData[i])
+				}
+			}
+		})
+	}
+
+	for _, input := range []string{"", "  \n", "[]", "[{"} {
+		if _, err := splitNotecardRequests(input); err == nil {
+			t.Errorf("splitNotecardRequests(%q) accepted an input without requests", input)
+		}
+	}
+}
+
+// compactJSON returns JSON without insignificant whitespace, or the data as is if it is not valid JSON
+func compactJSON(data json.RawMessage) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return string(data)
+	}
+	return compact.String()
+}
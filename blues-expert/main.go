@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"note-mcp/blues-expert/lib"
 
@@ -16,12 +19,22 @@ import (
 var (
 	envFilePath    string
 	logLevel       string
+	docsDir        string
+	offline        bool
+	schemaDir      string
+	searchBackends string
+	transport      string
 	sessionManager *lib.SessionManager
 )
 
 func init() {
 	flag.StringVar(&envFilePath, "env", "", "Path to .env file to load environment variables")
+	flag.StringVar(&transport, "transport", "http", "MCP transport to serve: stdio, http (streamable HTTP) or sse")
 	flag.StringVar(&logLevel, "log-level", "info", "Log level (trace, debug, info, warn, error, fatal, panic)")
+	flag.BoolVar(&offline, "offline", false, "Use the bundled Notecard API schema snapshot instead of fetching it from GitHub")
+	flag.StringVar(&searchBackends, "search-backends", lib.DefaultSearchBackends, "Comma-separated docs_search backends to try in order: ragpi[=url], opensearch=url, local")
+	flag.StringVar(&docsDir, "docs-dir", "", "Directory of firmware documents ({sdk}/{document_type}.md) to overlay onto the embedded docs, reloaded on change")
+	flag.StringVar(&schemaDir, "schema-dir", "", "Directory of Notecard API schema versions to load from disk, one subdirectory per version")
 }
 
 // panicRecoveryMiddleware wraps an HTTP handler with panic recovery
@@ -57,6 +70,13 @@ func main() {
 	// Initialize logger with specified log level
 	lib.InitLogger(logLevel)
 
+	// Check the transport before doing any other work
+	switch transport {
+	case "stdio", "http", "sse":
+	default:
+		log.Fatal().Str("transport", transport).Msg("Invalid transport, must be one of: stdio, http, sse")
+	}
+
 	// Load environment variables from .env file if specified
 	if envFilePath != "" {
 		log.Info().Str("path", envFilePath).Msg("Loading environment variables")
@@ -66,6 +86,35 @@ func main() {
 		}
 	}
 
+	// Use the bundled schema snapshot if running offline
+	if offline {
+		log.Info().Msg("Offline mode enabled, using bundled Notecard API schema snapshot")
+		lib.SetOfflineMode(true)
+	}
+
+	// Load pinned schema versions from disk if configured
+	if schemaDir != "" {
+		log.Info().Str("dir", schemaDir).Msg("Loading Notecard API schema versions from disk")
+		lib.SetSchemaDir(schemaDir)
+	}
+
+	// Overlay firmware documents from disk if configured
+	if docsDir != "" {
+		log.Info().Str("dir", docsDir).Msg("Loading firmware documentation from disk")
+		if err := lib.SetDocsDir(docsDir); err != nil {
+			log.Fatal().Err(err).Str("dir", docsDir).Msg("Failed to load firmware documentation")
+		}
+	}
+
+	// Configure the documentation search backends
+	if err := lib.SetSearchBackends(searchBackends); err != nil {
+		log.Fatal().Err(err).Str("backends", searchBackends).Msg("Invalid search backend configuration")
+	}
+	log.Info().Str("backends", searchBackends).Msg("Documentation search backends configured")
+
+	// Build the local documentation search index used when the search API is unavailable
+	go lib.BuildLocalSearchIndex()
+
 	// Initialize session manager
 	sessionManager = lib.NewSessionManager()
 
@@ -74,6 +123,10 @@ func main() {
 	opts := &mcp.ServerOptions{
 		Instructions: "This MCP server provides expert guidance on using the Blues Notecard & Notehub. When using this tool for developing firmware, use the 'firmware_entrypoint' tool to get started. Otherwise, use the 'docs_search' tool to search the Blues documentation.",
 		HasTools:     true,
+		HasPrompts:   true,
+		HasResources: true,
+		// Suggest SDKs, document types and API names for prompt and resource template arguments
+		CompletionHandler: lib.HandleCompletion,
 	}
 	s := mcp.NewServer(impl, opts)
 
@@ -83,17 +136,84 @@ func main() {
 	// Add tools
 	firmwareEntrypointTool := CreateFirmwareEntrypointTool()
 	firmwareBestPracticesTool := CreateFirmwareBestPracticesTool()
+	firmwareDocsListTool := CreateFirmwareDocsListTool()
 	apiValidateTool := CreateAPIValidateTool()
+	apiValidateResponseTool := CreateAPIValidateResponseTool()
+	apiValidateBatchTool := CreateAPIValidateBatchTool()
+	sourceValidateTool := CreateSourceValidateTool()
 	apiDocsTool := CreateAPIDocsTool()
+	apiSearchTool := CreateAPISearchTool()
+	apiDiffTool := CreateAPIDiffTool()
+	templateCheckTool := CreateTemplateCheckTool()
+	dataUsageEstimateTool := CreateDataUsageEstimateTool()
+	powerEstimateTool := CreatePowerEstimateTool()
+	notecardSimulateTool := CreateNotecardSimulateTool()
 	docsSearchTool := CreateDocsSearchTool()
 
 	// Add tool handlers
 	mcp.AddTool(s, firmwareEntrypointTool, lib.HandleFirmwareEntrypointTool)
 	mcp.AddTool(s, firmwareBestPracticesTool, lib.HandleFirmwareBestPracticesTool)
+	mcp.AddTool(s, firmwareDocsListTool, lib.HandleFirmwareDocsListTool)
 	mcp.AddTool(s, apiValidateTool, lib.HandleAPIValidateTool)
+	mcp.AddTool(s, apiValidateResponseTool, lib.HandleAPIValidateResponseTool)
+	mcp.AddTool(s, apiValidateBatchTool, lib.HandleAPIValidateBatchTool)
+	mcp.AddTool(s, sourceValidateTool, lib.HandleSourceValidateTool)
 	mcp.AddTool(s, apiDocsTool, lib.HandleAPIDocsTool)
+	mcp.AddTool(s, apiSearchTool, lib.HandleAPISearchTool)
+	mcp.AddTool(s, apiDiffTool, lib.HandleAPIDiffTool)
+	mcp.AddTool(s, templateCheckTool, lib.HandleTemplateCheckTool)
+	mcp.AddTool(s, dataUsageEstimateTool, lib.HandleDataUsageEstimateTool)
+	mcp.AddTool(s, powerEstimateTool, lib.HandlePowerEstimateTool)
+	mcp.AddTool(s, notecardSimulateTool, lib.HandleNotecardSimulateTool)
 	mcp.AddTool(s, docsSearchTool, lib.HandleDocsSearchTool)
 
+	// Add firmware workflow prompts
+	s.AddPrompt(CreateNewSensorProjectPrompt(), lib.HandleNewSensorProjectPrompt)
+	s.AddPrompt(CreateDebugSyncPrompt(), lib.HandleDebugSyncPrompt)
+	s.AddPrompt(CreateReducePowerPrompt(), lib.HandleReducePowerPrompt)
+
+	// Add firmware documentation resources
+	docResourceURIs := addDocResources(s, nil)
+	s.AddResourceTemplate(CreateDocResourceTemplate(), lib.HandleDocResource)
+
+	// Watch the docs directory, regenerating the firmware_entrypoint and firmware_best_practices
+	// enums and the documentation resources when documents are added, changed or removed
+	if docsDir != "" {
+		go lib.WatchDocsDir(context.Background(), func() {
+			mcp.AddTool(s, CreateFirmwareEntrypointTool(), lib.HandleFirmwareEntrypointTool)
+			mcp.AddTool(s, CreateFirmwareBestPracticesTool(), lib.HandleFirmwareBestPracticesTool)
+			docResourceURIs = addDocResources(s, docResourceURIs)
+		})
+	}
+
+	// Add Notecard API schema resources. The schema may need to be fetched, so the individual
+	// APIs are listed in the background; the template can be read in the meantime.
+	s.AddResourceTemplate(CreateAPIResourceTemplate(), lib.HandleAPIResource)
+	go func() {
+		apiResources, err := CreateAPIResources()
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to list Notecard API resources")
+			return
+		}
+		for _, resource := range apiResources {
+			s.AddResource(resource, lib.HandleAPIResource)
+		}
+		log.Info().Int("count", len(apiResources)).Msg("Notecard API resources added")
+	}()
+
+	// Serve over stdio for clients that launch the server as a subprocess. Logs are written to
+	// stderr, so stdout carries only MCP messages.
+	if transport == "stdio" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		log.Info().Msg("Serving MCP over stdio")
+		if err := s.Run(ctx, &mcp.StdioTransport{}); err != nil && ctx.Err() == nil {
+			log.Fatal().Err(err).Msg("MCP stdio server failed")
+		}
+		return
+	}
+
 	// Get port from environment variable (AppRunner provides this)
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -110,17 +230,24 @@ func main() {
 		w.Write([]byte("OK"))
 	})
 
-	// Create StreamableHTTPHandler for MCP requests
-	httpHandler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
-		return s
-	}, nil)
+	// Create the HTTP handler for MCP requests: StreamableHTTPHandler, or SSEHandler for older clients
+	var httpHandler http.Handler
+	if transport == "sse" {
+		httpHandler = mcp.NewSSEHandler(func(*http.Request) *mcp.Server {
+			return s
+		}, nil)
+	} else {
+		httpHandler = mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+			return s
+		}, nil)
+	}
 
 	// Route MCP server requests to /expert/ path with panic recovery
 	mux.HandleFunc("/expert/", panicRecoveryMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		httpHandler.ServeHTTP(w, r)
 	}))
 
-	log.Info().Str("port", port).Msg("Starting HTTP server")
+	log.Info().Str("port", port).Str("transport", transport).Msg("Starting HTTP server")
 	log.Info().Msg("MCP server available at /expert/")
 	log.Info().Msg("Health check at /expert/health")
 
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// sdkPromptArgument is the SDK argument shared by every prompt
+var sdkPromptArgument = &mcp.PromptArgument{
+	Name:        "sdk",
+	Title:       "SDK",
+	Description: "The SDK used by the firmware project. Must be one of: arduino, c, zephyr, python",
+	Required:    true,
+}
+
+// Firmware Workflow Prompts
+func CreateNewSensorProjectPrompt() *mcp.Prompt {
+	return &mcp.Prompt{
+		Name:        "new_sensor_project",
+		Title:       "New Notecard sensor project",
+		Description: "Start a new firmware project that reads a sensor and sends its readings to Notehub through the Notecard, with the SDK guide and best practices attached.",
+		Arguments: []*mcp.PromptArgument{
+			sdkPromptArgument,
+			{
+				Name:        "sensor",
+				Title:       "Sensor",
+				Description: "The sensor to read (e.g., 'BME280 temperature and humidity sensor')",
+				Required:    true,
+			},
+			{
+				Name:        "sync_cadence",
+				Title:       "Sync cadence",
+				Description: "How often readings should be synced to Notehub (e.g., 'every 15 minutes'). Defaults to every 60 minutes",
+			},
+			{
+				Name:        "product_uid",
+				Title:       "Product UID",
+				Description: "The Notehub product UID (e.g., 'com.my-company.my-name:my-project')",
+			},
+		},
+	}
+}
+
+func CreateDebugSyncPrompt() *mcp.Prompt {
+	return &mcp.Prompt{
+		Name:        "debug_sync",
+		Title:       "Debug a Notecard that won't sync",
+		Description: "Diagnose why a Notecard is not syncing with Notehub, with the SDK's debugging and connectivity guides attached.",
+		Arguments: []*mcp.PromptArgument{
+			sdkPromptArgument,
+			{
+				Name:        "symptoms",
+				Title:       "Symptoms",
+				Description: "What is going wrong (e.g., 'hub.sync.status reports a timeout, no events reach Notehub')",
+			},
+		},
+	}
+}
+
+func CreateReducePowerPrompt() *mcp.Prompt {
+	return &mcp.Prompt{
+		Name:        "reduce_power",
+		Title:       "Reduce power consumption",
+		Description: "Reduce the power consumption of a Notecard project, with the SDK's power management guide and best practices attached.",
+		Arguments: []*mcp.PromptArgument{
+			sdkPromptArgument,
+			{
+				Name:        "power_source",
+				Title:       "Power source",
+				Description: "How the device is powered (e.g., 'LiPo battery', '3x AA alkaline', 'solar with LiPo')",
+			},
+			{
+				Name:        "sync_cadence",
+				Title:       "Sync cadence",
+				Description: "How often data must reach Notehub (e.g., 'hourly')",
+			},
+		},
+	}
+}
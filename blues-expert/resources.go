@@ -1 +1,90 @@
 package main
+
+import (
+	"slices"
+
+	"note-mcp/blues-expert/lib"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Firmware Documentation Resources
+func CreateDocResources() []*mcp.Resource {
+	var resources []*mcp.Resource
+	for _, doc := range lib.ListDocs() {
+		description := "Notecard firmware documentation for the " + doc.SDK + " SDK."
+		if doc.Summary != "" {
+			description = doc.Summary
+		}
+		resources = append(resources, &mcp.Resource{
+			URI:         doc.URI,
+			Name:        doc.SDK + "/" + doc.DocumentType,
+			Title:       doc.Title,
+			Description: description,
+			MIMEType:    "text/markdown",
+			Size:        doc.Size,
+		})
+	}
+	return resources
+}
+
+// addDocResources adds the firmware documentation resources to the server, removing previously
+// added resources whose documents no longer exist, and returns the URIs of the resources
+func addDocResources(s *mcp.Server, previous []string) []string {
+	var uris []string
+	for _, resource := range CreateDocResources() {
+		s.AddResource(resource, lib.HandleDocResource)
+		uris = append(uris, resource.URI)
+	}
+
+	var removed []string
+	for _, uri := range previous {
+		if !slices.Contains(uris, uri) {
+			removed = append(removed, uri)
+		}
+	}
+	if len(removed) > 0 {
+		s.RemoveResources(removed...)
+	}
+	return uris
+}
+
+func CreateDocResourceTemplate() *mcp.ResourceTemplate {
+	return &mcp.ResourceTemplate{
+		URITemplate: lib.DocsResourcePrefix + "{sdk}/{document_type}",
+		Name:        "firmware_docs",
+		Title:       "Notecard Firmware Documentation",
+		Description: "Notecard firmware documentation for an SDK (arduino, c, zephyr, python) and document type (e.g. index, best_practices, templates, debugging, connectivity, sensors, power_management).",
+		MIMEType:    "text/markdown",
+	}
+}
+
+// Notecard API Resources
+func CreateAPIResources() ([]*mcp.Resource, error) {
+	names, err := lib.ListAPIResourceNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []*mcp.Resource
+	for _, name := range names {
+		resources = append(resources, &mcp.Resource{
+			URI:         lib.APIResourcePrefix + name,
+			Name:        name,
+			Title:       name + " request schema",
+			Description: "JSON schema of the Notecard " + name + " request.",
+			MIMEType:    "application/schema+json",
+		})
+	}
+	return resources, nil
+}
+
+func CreateAPIResourceTemplate() *mcp.ResourceTemplate {
+	return &mcp.ResourceTemplate{
+		URITemplate: lib.APIResourcePrefix + "{name}",
+		Name:        "notecard_api_schema",
+		Title:       "Notecard API Request Schema",
+		Description: "JSON schema of a Notecard API request (e.g. card.version, hub.set, note.add).",
+		MIMEType:    "application/schema+json",
+	}
+}
@@ -1,12 +1,26 @@
 package main
 
 import (
+	"slices"
+	"strings"
+
+	"note-mcp/blues-expert/lib"
+
 	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // Firmware Tools
 func CreateFirmwareEntrypointTool() *mcp.Tool {
+	// The enum is generated from the firmware documentation, including any docs directory overlay:
+	// every SDK with an index document
+	var sdks []string
+	for _, sdk := range lib.ListDocSDKs() {
+		if slices.Contains(lib.ListDocTypes(sdk), "index") {
+			sdks = append(sdks, sdk)
+		}
+	}
+
 	return &mcp.Tool{
 		Name:        "firmware_entrypoint",
 		Description: "Get a starting point for a firmware project. This tool will return information about developing firmware for the Notecard using a specific SDK. ALWAYS use this tool when writing code, before using any other tools as it contains critial information about Notecard implementation.",
@@ -15,13 +29,8 @@ func CreateFirmwareEntrypointTool() *mcp.Tool {
 			Properties: map[string]*jsonschema.Schema{
 				"sdk": {
 					Type:        "string",
-					Description: "The sdk to use for the firmware project. Must be one of: Arduino, C, Zephyr, Python",
-					Enum: []any{
-						"Arduino",
-						"C",
-						"Zephyr",
-						"Python",
-					},
+					Description: "The SDK to use for the firmware project. Must be one of: " + strings.Join(sdks, ", "),
+					Enum:        stringEnum(sdks),
 				},
 			},
 			Required: []string{"sdk"},
@@ -30,25 +39,37 @@ func CreateFirmwareEntrypointTool() *mcp.Tool {
 }
 
 func CreateFirmwareBestPracticesTool() *mcp.Tool {
+	// The enums are generated from the firmware documentation, including any docs directory overlay
+	sdks := lib.ListDocSDKs()
+	documentTypes := lib.ListDocTypes("")
+
 	return &mcp.Tool{
 		Name:        "firmware_best_practices",
-		Description: "Get best practices documentation for firmware development with the Notecard. Returns detailed guidance on specific topics like power management, sensors, templates, etc. for a given SDK.",
+		Description: "Get best practices documentation for firmware development with the Notecard. Returns detailed guidance on specific topics like power management, sensors, templates, etc. for a given SDK. Not every SDK has every document type; use the 'firmware_docs_list' tool to see the documents available for an SDK. Long documents can be browsed with 'toc' and read one 'section' at a time, or filtered by 'keyword'. Argument completion is not available for tools; clients that support completion can complete the sdk and document_type of the blues://docs/{sdk}/{document_type} resource template instead.",
 		InputSchema: &jsonschema.Schema{
 			Type: "object",
 			Properties: map[string]*jsonschema.Schema{
 				"sdk": {
 					Type:        "string",
-					Description: "The SDK to use for the firmware project. Must be one of: arduino, c, zephyr, python",
-					Enum: []any{
-						"arduino",
-						"c",
-						"zephyr",
-						"python",
-					},
+					Description: "The SDK to use for the firmware project. Must be one of: " + strings.Join(sdks, ", "),
+					Enum:        stringEnum(sdks),
 				},
 				"document_type": {
 					Type:        "string",
-					Description: "The type of documentation to retrieve (e.g., 'best_practices', 'templates', 'debugging', 'connectivity', 'sensors', 'power_management')",
+					Description: "The type of documentation to retrieve. Must be one of: " + strings.Join(documentTypes, ", "),
+					Enum:        stringEnum(documentTypes),
+				},
+				"section": {
+					Type:        "string",
+					Description: "Optional heading or anchor of the section to return (e.g., 'creating-a-template', 'Verifying a Template'). The section is returned with its subsections",
+				},
+				"keyword": {
+					Type:        "string",
+					Description: "Optional keyword. Only the sections mentioning it are returned",
+				},
+				"toc": {
+					Type:        "boolean",
+					Description: "If true, return the document's table of contents instead of its content. Use this first for long documents",
 				},
 			},
 			Required: []string{"sdk", "document_type"},
@@ -56,18 +77,97 @@ func CreateFirmwareBestPracticesTool() *mcp.Tool {
 	}
 }
 
+func CreateFirmwareDocsListTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "firmware_docs_list",
+		Description: "List the firmware documentation available for each SDK, with the title, summary and tags of each document. Use this to find the document_type to pass to 'firmware_best_practices'.",
+	}
+}
+
+// stringEnum converts a list of strings to JSON schema enum values
+func stringEnum(values []string) []any {
+	enum := make([]any, 0, len(values))
+	for _, value := range values {
+		enum = append(enum, value)
+	}
+	return enum
+}
+
 // Notecard API Tools
 func CreateAPIValidateTool() *mcp.Tool {
 	return &mcp.Tool{
 		Name:        "api_validate",
-		Description: "Validate a Notecard API request against the Notecard API Schema. This should be used to ensure that Notecard requests/commands are valid for use in firmware projects.",
+		Description: "Validate a Notecard API request against the Notecard API Schema. This should be used to ensure that Notecard requests/commands are valid for use in firmware projects. Optionally provide the target Notecard SKU (e.g. NOTE-WBNAN, NOTE-MBGLB) and firmware version to also flag requests, properties and values that are not supported on that hardware, or that the schema records as requiring newer firmware.",
+	}
+}
+
+func CreateAPIValidateResponseTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "api_validate_response",
+		Description: "Validate a Notecard API response against the response schema of the request it answers. Provide the request as an API name (e.g. card.version) or the JSON request, and the JSON response captured from the Notecard. Use this to check that parsing code and captured logs match what the Notecard is documented to return. Type mismatches make the response invalid; properties the schema does not document are listed separately, and an 'err' returned by the Notecard is reported.",
+	}
+}
+
+func CreateAPIValidateBatchTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "api_validate_batch",
+		Description: "Validate many Notecard API requests at once against the Notecard API Schema. Accepts a JSON array of requests or a sequence of requests (newline-delimited JSON or pretty-printed objects one after another), and returns a pass/fail result for each request by index along with a summary. Use this to check all of the requests a firmware project issues (e.g. during initialization) in a single call.",
+	}
+}
+
+func CreateSourceValidateTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "source_validate",
+		Description: "Validate the Notecard requests built in firmware source code. Statically reconstructs requests from note-c (NoteNewRequest/JAdd*ToObject), note-arduino (notecard.newRequest), Python (card.Transaction({...})) and raw JSON string literals, then validates each one against the Notecard API Schema. Results include the source line, the reconstructed request and any findings. Property values computed at runtime are listed as dynamic and only checked for their name and implied type.",
+	}
+}
+
+func CreateAPIDiffTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "api_diff",
+		Description: "Compare two versions of the Notecard API schema. Reports added and removed APIs, and for changed APIs the added, removed and renamed properties along with changes to types, enums, minimum/maximum ranges and SKU lists. Optionally pass the requests your firmware uses to find out which of them are affected. Use this when upgrading Notecard firmware.",
 	}
 }
 
 func CreateAPIDocsTool() *mcp.Tool {
 	return &mcp.Tool{
 		Name:        "api_docs",
-		Description: "Get detailed documentation for a specific Notecard API. Returns comprehensive API information including parameters, descriptions, types, and usage examples. APIs may be called using 'req' or 'cmd' properties, where 'req' returns a response and 'cmd' does not. If no API is provided, returns a list of all available APIs and their descriptions. When reading descriptions, if a markdown link is provided, append 'https://dev.blues.io' to the start of the link in order to follow it.",
+		Description: "Get detailed documentation for a specific Notecard API. Returns comprehensive API information including parameters, descriptions, types, and usage examples. APIs may be called using 'req' or 'cmd' properties, where 'req' returns a response and 'cmd' does not. If no API is provided, returns a list of all available APIs and their descriptions. When reading JSON descriptions, if a markdown link is provided, append 'https://dev.blues.io' to the start of the link in order to follow it. Use format 'markdown' for a readable reference with property tables, enum values, SKUs and samples, or 'compact' for a one-line-per-property summary. Argument completion is not available for tools; use 'api_search' to find API names, or complete the name of the blues://api/{name} resource template in clients that support completion.",
+	}
+}
+
+func CreateAPISearchTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "api_search",
+		Description: "Find Notecard APIs by keyword, namespace and SKU support. Keywords are matched against API names, descriptions and property descriptions, and the APIs are returned as a ranked short list with the properties that mention the keywords. Use this to find the right API for a task (e.g. 'gps tracking' in the card namespace on LORA Notecards), then the 'api_docs' tool for its full documentation.",
+	}
+}
+
+func CreateTemplateCheckTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "template_check",
+		Description: "Check a Note template and the size of its Notes. Validates a note.template body (or whole request) against the template type rules: booleans must be true, integers 11, 12, 13, 14, 18 or unsigned 21, 22, 23, 24, floats 12.1, 14.1 or 18.1, strings a maximum length such as \"42\" or variable-length text, and the compact-format fields _lat, _lon, _time and _ltime. Reports the type and byte size of each field and the bytes per Note. Given a sample note.add body or request, also reports the fields that would be dropped, truncated, overflow or be omitted as empty, and sizes variable-length fields from it.",
+	}
+}
+
+func CreateDataUsageEstimateTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "data_usage_estimate",
+		Description: "Estimate the cellular data a Notecard uses per sync and per month. Takes the hub.set request (mode, outbound and inbound minutes) and the Notefiles synced to Notehub, each with a template or sample Note and the number of Notes added per hour. Every request is validated first; Note sizes come from the template (as reported by note.template) or the JSON of the sample Note, and each Notehub session adds a fixed overhead. Sizes are before compression.",
+	}
+}
+
+func CreatePowerEstimateTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "power_estimate",
+		Description: "Estimate the average current and battery life of a Notecard and its host MCU. Takes the Notecard configuration requests (hub.set, card.location.mode, card.motion.mode), the Notecard SKU, the Notecard current figures measured on the device, the host MCU sleep and wake profile and the battery capacity. Every request is validated first. Returns the average current with a breakdown per activity (Notecard idle or continuous connection, sync sessions, GPS, accelerometer, host sleep and wake) and the source of each figure used. Measured figures take precedence over the published figures bundled with the server; activities without either are left out with a warning.",
+	}
+}
+
+func CreateNotecardSimulateTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        "notecard_simulate",
+		Description: "Simulate a Notecard without hardware. Takes a request or a sequence of requests, validates each against the Notecard API schema and returns a plausible response built from the response samples and definitions of the schema. With stateful set, note.add, note.get, file.changes, env.set, env.get, hub.set, hub.get and hub.sync are applied to an in-memory Notecard kept for the session, so that, for example, a Note added by one call can be read back by a later one; reset starts it afresh. When the response schemas cannot be loaded, bundled synthesized stand-ins are used and reported as such. Responses are examples for prototyping host firmware, not real device output.",
 	}
 }
 
@@ -75,6 +175,6 @@ func CreateAPIDocsTool() *mcp.Tool {
 func CreateDocsSearchTool() *mcp.Tool {
 	return &mcp.Tool{
 		Name:        "docs_search",
-		Description: "Search the blues.dev documentation for answers to questions about the Notecard, cellular connectivity, GPS, power management, Notehub, and other Notecard-specific topics.",
+		Description: "Search the blues.dev documentation for answers to questions about the Notecard, cellular connectivity, GPS, power management, Notehub, and other Notecard-specific topics. If the blues.dev search service is unavailable, the documentation and Notecard API reference bundled with this server are searched instead.",
 	}
 }